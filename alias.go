@@ -0,0 +1,77 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/reddec/editstruct/internal/config"
+	"github.com/reddec/editstruct/internal/editor"
+)
+
+// typeIndex records the alias declarations and defined types of the scanned files.
+type typeIndex struct {
+	aliases map[string]string
+	defined map[string]bool
+}
+
+func indexTypes(files []string) (typeIndex, error) {
+	idx := typeIndex{aliases: make(map[string]string), defined: make(map[string]bool)}
+	for _, path := range files {
+		ed, err := editor.ParseFile(path)
+		if err != nil {
+			return idx, err
+		}
+		for _, name := range ed.StructNames() {
+			if target, ok := ed.AliasTarget(name); ok {
+				idx.aliases[name] = target
+			} else {
+				idx.defined[name] = true
+			}
+		}
+	}
+	return idx, nil
+}
+
+// resolve follows a chain of aliases to the defined type. It reports false if
+// name is not an alias or the chain ends outside the scanned files, e.g. in
+// another package.
+func (idx typeIndex) resolve(name string) (string, bool) {
+	if _, ok := idx.aliases[name]; !ok {
+		return "", false
+	}
+	seen := make(map[string]bool)
+	for !seen[name] {
+		seen[name] = true
+		target, ok := idx.aliases[name]
+		if !ok {
+			return name, idx.defined[name]
+		}
+		name = target
+	}
+	return "", false
+}
+
+// followAliases adds a copy of every config whose type is an alias for the
+// type it resolves to, unless that type is configured explicitly.
+func followAliases(files []string, configs []config.TypeConfig) ([]config.TypeConfig, error) {
+	idx, err := indexTypes(files)
+	if err != nil {
+		return nil, err
+	}
+
+	configured := make(map[string]bool, len(configs))
+	for _, c := range configs {
+		configured[c.Type] = true
+	}
+
+	result := slices.Clone(configs)
+	for _, c := range configs {
+		target, ok := idx.resolve(c.Type)
+		if !ok || configured[target] {
+			continue
+		}
+		c.Type = target
+		configured[target] = true
+		result = append(result, c)
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+func TestFollowAliases(t *testing.T) {
+	dir := t.TempDir()
+	aliasPath := filepath.Join(dir, "alias.go")
+	err := os.WriteFile(aliasPath, []byte(`package test
+
+import "example.com/internal"
+
+type Order = orderAlias
+
+type orderAlias = order
+
+type External = internal.Order
+
+type Loop = Loop
+`), 0644)
+	require.NoError(t, err)
+	defPath := filepath.Join(dir, "order.go")
+	err = os.WriteFile(defPath, []byte(`package test
+
+type order struct {
+	Total *int64
+}
+`), 0644)
+	require.NoError(t, err)
+	files := []string{aliasPath, defPath}
+
+	idx, err := indexTypes(files)
+	require.NoError(t, err)
+
+	target, ok := idx.resolve("Order")
+	assert.True(t, ok)
+	assert.Equal(t, "order", target)
+	_, ok = idx.resolve("External")
+	assert.False(t, ok)
+	_, ok = idx.resolve("Loop")
+	assert.False(t, ok)
+	_, ok = idx.resolve("order")
+	assert.False(t, ok)
+
+	configs, err := followAliases(files, []config.TypeConfig{
+		{Type: "Order", Fields: map[string]string{"Total": "uint64"}},
+		{Type: "External", Fields: map[string]string{"Total": "uint64"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, configs, 3)
+	assert.Equal(t, "order", configs[2].Type)
+
+	for _, path := range files {
+		_, err := processFile(path, configs, options{})
+		require.NoError(t, err)
+	}
+	content, err := os.ReadFile(defPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Total uint64")
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"go/build"
+	"io"
+	"path/filepath"
+)
+
+// matchesBuild reports whether the default build context would build the
+// file at path with contents src, judging by its name (such as x_windows.go)
+// and its build constraints. Source read from stdin has no name of its own,
+// so only its constraints count.
+func matchesBuild(path string, src []byte) (bool, error) {
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	if path == stdinPath {
+		dir, name = ".", "stdin.go"
+	}
+	ctx := build.Default
+	ctx.OpenFile = func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(src)), nil
+	}
+	return ctx.MatchFile(dir, name)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"go/build"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesBuild(t *testing.T) {
+	other := "windows"
+	if runtime.GOOS == other {
+		other = "linux"
+	}
+
+	tests := []struct {
+		name string
+		path string
+		src  string
+		want bool
+	}{
+		{"no constraint", "types.go", "package a\n", true},
+		{"current platform", "types.go", "//go:build " + build.Default.GOOS + "\n\npackage a\n", true},
+		{"release tag and arch", "types.go", "//go:build go1.18 && " + build.Default.GOARCH + "\n\npackage a\n", true},
+		{"ignore tag", "types.go", "//go:build ignore\n\npackage a\n", false},
+		{"negated platform", "types.go", "//go:build !" + build.Default.GOOS + "\n\npackage a\n", false},
+		{"other platform file name", "types_" + other + ".go", "package a\n", false},
+		{"current platform file name", "types_" + build.Default.GOOS + ".go", "package a\n", true},
+		{"cgo", "types.go", "//go:build cgo\n\npackage a\n", build.Default.CgoEnabled},
+		{"not cgo", "types.go", "//go:build !cgo\n\npackage a\n", !build.Default.CgoEnabled},
+		{"stdin", stdinPath, "//go:build !" + build.Default.GOOS + "\n\npackage a\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := matchesBuild(tt.path, []byte(tt.src))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ok)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/reddec/editstruct/internal/config"
+	"github.com/reddec/editstruct/internal/editor"
+)
+
+// resolveEmbedded moves fields entries that address a field of an embedded
+// type, such as "Base.CreatedAt" for a struct embedding Base, into a document
+// for the embedded type, which may be declared in another scanned file. It
+// reports whether any entry was moved and fails if an embedded type is not
+// declared in the scanned files.
+func resolveEmbedded(files []string, configs []config.TypeConfig) ([]config.TypeConfig, bool, error) {
+	if !slices.ContainsFunc(configs, func(tc config.TypeConfig) bool {
+		return slices.ContainsFunc(slices.Collect(maps.Keys(tc.Fields)), func(key string) bool { return strings.Contains(key, ".") })
+	}) {
+		return configs, false, nil
+	}
+
+	var eds []*editor.Editor
+	declared := make(map[string]bool)
+	for _, path := range files {
+		ed, err := editor.ParseFile(path)
+		if err != nil {
+			return nil, false, err
+		}
+		eds = append(eds, ed)
+		for _, name := range ed.StructNames() {
+			declared[name] = true
+		}
+	}
+	embedded := func(structName, fieldName string) (string, bool) {
+		for _, ed := range eds {
+			if typ, ok := ed.EmbeddedType(structName, fieldName); ok {
+				return typ, true
+			}
+		}
+		return "", false
+	}
+
+	var moved bool
+	result := slices.Clone(configs)
+	// Derived documents are appended and resolved in turn, so paths through
+	// several embedded types work.
+	for i := 0; i < len(result); i++ {
+		tc := result[i]
+		derived := make(map[string]map[string]string)
+		for _, key := range slices.Sorted(maps.Keys(tc.Fields)) {
+			outer, inner, ok := strings.Cut(key, ".")
+			if !ok || tc.Type == "" {
+				continue
+			}
+			typ, ok := embedded(tc.Type, outer)
+			if !ok {
+				continue
+			}
+			if !declared[typ] {
+				return nil, false, fmt.Errorf("field %s.%s: embedded type %s is not declared in the scanned files", tc.Type, key, typ)
+			}
+			if derived[typ] == nil {
+				derived[typ] = make(map[string]string)
+			}
+			derived[typ][inner] = tc.Fields[key]
+		}
+		if len(derived) == 0 {
+			continue
+		}
+
+		fields := maps.Clone(tc.Fields)
+		for key := range fields {
+			outer, _, ok := strings.Cut(key, ".")
+			if _, embeds := embedded(tc.Type, outer); ok && embeds {
+				delete(fields, key)
+			}
+		}
+		result[i].Fields = fields
+		for _, typ := range slices.Sorted(maps.Keys(derived)) {
+			result = append(result, config.TypeConfig{
+				Type:        typ,
+				Fields:      derived[typ],
+				ImportPaths: tc.ImportPaths,
+				GoVersion:   tc.GoVersion,
+			})
+		}
+		moved = true
+	}
+	return result, moved, nil
+}
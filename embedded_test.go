@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+func TestResolveEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	basePath := filepath.Join(dir, "base.go")
+	modelPath := filepath.Join(dir, "model.go")
+	require.NoError(t, os.WriteFile(basePath, []byte("package test\n\ntype Base struct {\n\tCreatedAt string\n\tID        int\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(modelPath, []byte("package test\n\ntype Model struct {\n\t*Base\n\text.Meta\n\tName  string\n\tAddr  struct {\n\t\tZip int\n\t}\n}\n"), 0644))
+	files := []string{basePath, modelPath}
+
+	t.Run("edits the embedded type in its own file", func(t *testing.T) {
+		cfg, moved, err := resolveEmbedded(files, []config.TypeConfig{{
+			Type:   "Model",
+			Fields: map[string]string{"Base.CreatedAt": "time.Time", "Name": "[]byte", "Addr.Zip": "string"},
+		}})
+		require.NoError(t, err)
+		assert.True(t, moved)
+		assert.Equal(t, []config.TypeConfig{
+			{Type: "Model", Fields: map[string]string{"Name": "[]byte", "Addr.Zip": "string"}},
+			{Type: "Base", Fields: map[string]string{"CreatedAt": "time.Time"}},
+		}, cfg)
+
+		var staged []fileResult
+		for _, path := range files {
+			res, err := editFile(path, cfg, options{strict: true})
+			require.NoError(t, err)
+			staged = append(staged, res)
+		}
+		require.NoError(t, writeAll(staged))
+
+		content, err := os.ReadFile(basePath)
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\nimport (\n\t\"time\"\n)\n\ntype Base struct {\n\tCreatedAt time.Time\n\tID        int\n}\n", string(content))
+		content, err = os.ReadFile(modelPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "\tName []byte\n")
+		assert.Contains(t, string(content), "\t\tZip string\n")
+	})
+
+	t.Run("embedded type not declared", func(t *testing.T) {
+		_, _, err := resolveEmbedded(files, []config.TypeConfig{{
+			Type:   "Model",
+			Fields: map[string]string{"Meta.Version": "int64"},
+		}})
+		assert.EqualError(t, err, "field Model.Meta.Version: embedded type ext.Meta is not declared in the scanned files")
+	})
+
+	t.Run("nothing to resolve", func(t *testing.T) {
+		configs := []config.TypeConfig{{Type: "Model", Fields: map[string]string{"Name": "[]byte"}}}
+		cfg, moved, err := resolveEmbedded(files, configs)
+		require.NoError(t, err)
+		assert.False(t, moved)
+		assert.Equal(t, configs, cfg)
+	})
+}
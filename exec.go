@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// runExec runs an -exec command through sh with every {file} replaced by the
+// quoted path. The command's output goes to the tool's own stdout and stderr.
+func runExec(command, path string) error {
+	cmd := exec.Command("sh", "-c", strings.ReplaceAll(command, "{file}", shellQuote(path)))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("exec %s: %w", path, err)
+	}
+	return nil
+}
+
+// execAll runs the -exec command for every modified file.
+func execAll(command string, results []fileResult) error {
+	for _, res := range results {
+		if !res.modified {
+			continue
+		}
+		if err := runExec(command, res.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
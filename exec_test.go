@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+func TestRunExec(t *testing.T) {
+	t.Run("substitutes quoted path", func(t *testing.T) {
+		dir := t.TempDir()
+		out := filepath.Join(dir, "out")
+		path := filepath.Join(dir, "it's a file.go")
+
+		require.NoError(t, runExec("printf %s {file} > "+shellQuote(out), path))
+		content, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, path, string(content))
+	})
+
+	t.Run("non-zero exit fails", func(t *testing.T) {
+		err := runExec("exit 3", "types.go")
+		assert.ErrorContains(t, err, "exec types.go: exit status 3")
+	})
+
+	t.Run("only for modified files", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Total *int64
+}
+`), 0644))
+		opts := options{exec: "echo {file} >> " + shellQuote(filepath.Join(dir, "log"))}
+		cfg := []config.TypeConfig{{Type: "Example", Fields: map[string]string{"Total": "uint64"}}}
+
+		_, err := processFile(filePath, cfg, opts)
+		require.NoError(t, err)
+		_, err = processFile(filePath, cfg, opts)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filepath.Join(dir, "log"))
+		require.NoError(t, err)
+		assert.Equal(t, filePath+"\n", string(content))
+	})
+}
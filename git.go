@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// changedFiles lists the files in the current directory that differ from the
+// given git revision, as well as untracked files that are not ignored,
+// relative to the current directory.
+func changedFiles(base string) (map[string]bool, error) {
+	changed := make(map[string]bool)
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", base, "--", "."},
+		{"ls-files", "--others", "--exclude-standard", "--", "."},
+	} {
+		var stderr bytes.Buffer
+		cmd := exec.Command("git", args...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if line != "" {
+				changed[filepath.FromSlash(line)] = true
+			}
+		}
+	}
+	return changed, nil
+}
+
+// filterChanged keeps the files listed in changed, comparing paths relative to
+// the current directory.
+func filterChanged(files []string, changed map[string]bool) ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, file := range files {
+		// Git reports paths relative to the current directory, while files may
+		// be given as ./a.go or as absolute paths.
+		rel := filepath.Clean(file)
+		if filepath.IsAbs(rel) {
+			if rel, err = filepath.Rel(wd, rel); err != nil {
+				continue
+			}
+		}
+		if changed[rel] {
+			result = append(result, file)
+		}
+	}
+	return result, nil
+}
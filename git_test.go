@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Run("changed since base", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		git := func(args ...string) {
+			out, err := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+			require.NoError(t, err, string(out))
+		}
+		git("init", "-q")
+		require.NoError(t, os.WriteFile("a.go", []byte("package a\n"), 0644))
+		require.NoError(t, os.WriteFile("b.go", []byte("package a\n"), 0644))
+		git("add", ".")
+		git("commit", "-q", "-m", "init")
+		require.NoError(t, os.WriteFile("b.go", []byte("package a\n\nvar X int\n"), 0644))
+		require.NoError(t, os.WriteFile("c.go", []byte("package a\n"), 0644))
+		require.NoError(t, os.WriteFile("ignored.go", []byte("package a\n"), 0644))
+		require.NoError(t, os.WriteFile(".gitignore", []byte("ignored.go\n"), 0644))
+
+		changed, err := changedFiles("HEAD")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"b.go": true, "c.go": true, ".gitignore": true}, changed)
+		filtered, err := filterChanged([]string{"a.go", "b.go", "c.go", "ignored.go"}, changed)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b.go", "c.go"}, filtered)
+
+		// Paths are matched however they were given on the command line.
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		abs := filepath.Join(wd, "c.go")
+		filtered, err = filterChanged([]string{"./a.go", "./b.go", abs}, changed)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"./b.go", abs}, filtered)
+	})
+
+	t.Run("committed since base", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		git := func(args ...string) {
+			out, err := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...).CombinedOutput()
+			require.NoError(t, err, string(out))
+		}
+		git("init", "-q")
+		require.NoError(t, os.WriteFile("a.go", []byte("package a\n"), 0644))
+		git("add", ".")
+		git("commit", "-q", "-m", "init")
+		git("branch", "base")
+		require.NoError(t, os.WriteFile("b.go", []byte("package a\n"), 0644))
+		git("add", ".")
+		git("commit", "-q", "-m", "branch work")
+
+		changed, err := changedFiles("base")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"b.go": true}, changed)
+	})
+
+	t.Run("not a repository", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(dir))
+		t.Chdir(dir)
+
+		_, err := changedFiles("HEAD")
+		assert.Error(t, err)
+	})
+}
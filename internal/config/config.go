@@ -1,54 +1,458 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"go/version"
+	"io"
+	"maps"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
+// ErrParse is returned by Load when the configuration cannot be decoded.
+var ErrParse = errors.New("parse config")
+
 type TypeConfig struct {
-	Type   string            `yaml:"type"`
-	Fields map[string]string `yaml:"fields"`
+	Type          string                       `yaml:"type,omitempty"`
+	Fields        map[string]string            `yaml:"fields,omitempty"`
+	Rename        map[string]string            `yaml:"rename,omitempty"`
+	Add           map[string]string            `yaml:"add,omitempty"`
+	Remove        []string                     `yaml:"remove,omitempty"`
+	Index         map[int]string               `yaml:"index,omitempty"`
+	Except        []string                     `yaml:"except,omitempty"`
+	LineComments  map[string]string            `yaml:"lineComments,omitempty"`
+	TypeDoc       string                       `yaml:"typeDoc,omitempty"`
+	RenameType    string                       `yaml:"renameType,omitempty"`
+	Methods       map[string]string            `yaml:"methods,omitempty"`
+	ImportPaths   map[string]string            `yaml:"imports,omitempty"`
+	SortFields    bool                         `yaml:"sortFields,omitempty"`
+	ChanDir       map[string]string            `yaml:"chanDir,omitempty"`
+	MapKey        map[string]string            `yaml:"mapKey,omitempty"`
+	ChanElem      map[string]string            `yaml:"chanElem,omitempty"`
+	FuncParams    map[string]map[int]string    `yaml:"funcParams,omitempty"`
+	FuncResults   map[string]map[int]string    `yaml:"funcResults,omitempty"`
+	EmbedPointer  map[string]bool              `yaml:"embedPointer,omitempty"`
+	UnwrapPointer map[string]string            `yaml:"unwrapPointer,omitempty"`
+	Tags          map[string]map[string]string `yaml:"tags,omitempty"`
+	MergeTags     bool                         `yaml:"merge,omitempty"`
+	ToSlice       []string                     `yaml:"toSlice,omitempty"`
+	FromSlice     []string                     `yaml:"fromSlice,omitempty"`
+	DropTag       []string                     `yaml:"dropTag,omitempty"`
+	GoVersion     string                       `yaml:"goVersion,omitempty"`
+	When          Condition                    `yaml:"when,omitempty"`
+	Where         FieldCondition               `yaml:"where,omitempty"`
+	Vars          map[string]string            `yaml:"vars,omitempty"`
+	ReplaceTypes  map[string]string            `yaml:"globalReplaceTypes,omitempty"`
+}
+
+// Condition restricts a document to files that satisfy it. The zero value
+// matches every file.
+type Condition struct {
+	Imports []string `yaml:"imports"`
+}
+
+// Matches reports whether a file with the given import paths satisfies the
+// condition: every listed import must be present.
+func (c Condition) Matches(importPaths []string) bool {
+	for _, want := range c.Imports {
+		if !slices.Contains(importPaths, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// FieldCondition restricts the fields edited by fields and index to those
+// whose struct tag has the key Tag with a value containing Contains. The zero
+// value matches every field.
+type FieldCondition struct {
+	Tag      string `yaml:"tag,omitempty"`
+	Contains string `yaml:"contains,omitempty"`
+}
+
+// Matches reports whether a field with the given struct tag, without the
+// quotes, satisfies the condition.
+func (c FieldCondition) Matches(tag string) bool {
+	if c.Tag == "" {
+		return true
+	}
+	value, ok := reflect.StructTag(tag).Lookup(c.Tag)
+	return ok && strings.Contains(value, c.Contains)
 }
 
 func Load(path string) ([]TypeConfig, error) {
+	configs, _, err := LoadWithWarnings(path)
+	return configs, err
+}
+
+// LoadWithWarnings is like Load but also describes the documents it ignored
+// although they look like they were meant to do something.
+func LoadWithWarnings(path string) ([]TypeConfig, []string, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("read config: %w", err)
+		return nil, nil, fmt.Errorf("read config: %w", err)
+	}
+
+	// Editors on Windows may add a byte order mark and CRLF line endings;
+	// a CR left in a scalar would end up in the generated code.
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	decode := decodeYAML
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decode = decodeJSON
+	}
+	nodes, err := decode(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrParse, err)
 	}
 
 	var configs []TypeConfig
-	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	var warnings []string
+	globalImports := make(map[string]string)
+	var globalVersion string
+	var docs []int
 
-	for {
+	for i, node := range nodes {
+		doc := i + 1
+		nameKeys(node)
 		var cfg TypeConfig
-		err := decoder.Decode(&cfg)
-		if err != nil {
-			if err.Error() == "EOF" {
-				break
+		if err := node.Decode(&cfg); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrParse, err)
+		}
+		if cfg.Type == "" {
+			// Imports of type-less documents are shared by every document.
+			maps.Copy(globalImports, cfg.ImportPaths)
+			if cfg.GoVersion != "" {
+				globalVersion = cfg.GoVersion
 			}
-			return nil, fmt.Errorf("parse config: %w", err)
 		}
-		if cfg.Type != "" && len(cfg.Fields) > 0 {
+		switch {
+		case cfg.Type != "" && cfg.hasDirectives() || len(cfg.Vars) > 0 || len(cfg.ReplaceTypes) > 0:
 			configs = append(configs, cfg)
+			docs = append(docs, doc)
+		case cfg.Type != "":
+			warnings = append(warnings, fmt.Sprintf("document %d (type %s) has no directives and is ignored", doc, cfg.Type))
+		case cfg.hasDirectives():
+			warnings = append(warnings, fmt.Sprintf("document %d has directives but no type and is ignored", doc))
+		}
+	}
+
+	for i := range configs {
+		for alias, path := range globalImports {
+			if _, ok := configs[i].ImportPaths[alias]; !ok {
+				if configs[i].ImportPaths == nil {
+					configs[i].ImportPaths = make(map[string]string)
+				}
+				configs[i].ImportPaths[alias] = path
+			}
+		}
+		if configs[i].GoVersion == "" {
+			configs[i].GoVersion = globalVersion
+		}
+		if err := configs[i].checkSyntax(); err != nil {
+			return nil, nil, fmt.Errorf("document %d: %w", docs[i], err)
+		}
+	}
+
+	return configs, warnings, nil
+}
+
+// decodeYAML splits a multi-document YAML stream into its documents.
+func decodeYAML(data []byte) ([]*yaml.Node, error) {
+	var nodes []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nodes, nil
+			}
+			return nil, err
+		}
+		nodes = append(nodes, &node)
+	}
+}
+
+// decodeJSON reads a JSON array whose objects are the documents.
+func decodeJSON(data []byte) ([]*yaml.Node, error) {
+	// JSON is valid YAML, so the documents decode like YAML ones. YAML is
+	// more lenient though, e.g. about trailing commas, so check strictly first.
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.SequenceNode {
+		return nil, errors.New("JSON config must be an array of objects")
+	}
+	for _, node := range root.Content[0].Content {
+		if node.Kind != yaml.MappingNode {
+			return nil, errors.New("JSON config must be an array of objects")
+		}
+		unquoteKeys(node)
+	}
+	return root.Content[0].Content, nil
+}
+
+// unquoteKeys lets numeric JSON object keys resolve like plain YAML keys, so
+// that "2" in an index map decodes as an int.
+func unquoteKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if _, err := strconv.Atoi(key.Value); err == nil && key.Style == yaml.DoubleQuotedStyle {
+				key.Style, key.Tag = 0, ""
+			}
+		}
+	}
+	for _, child := range node.Content {
+		unquoteKeys(child)
+	}
+}
+
+// genericsVersion is the first Go version that accepts generic types.
+const genericsVersion = "go1.18"
+
+// checkSyntax rejects new types that the document's goVersion cannot build.
+// Without goVersion the running toolchain's version is assumed.
+func (tc TypeConfig) checkSyntax() error {
+	if tc.RenameType != "" && !token.IsIdentifier(tc.RenameType) {
+		return fmt.Errorf("invalid renameType %q", tc.RenameType)
+	}
+	for _, name := range slices.Sorted(maps.Values(tc.Rename)) {
+		if !token.IsIdentifier(name) {
+			return fmt.Errorf("invalid rename target %q", name)
+		}
+	}
+	target := runtime.Version()
+	if tc.GoVersion != "" {
+		target = "go" + strings.TrimPrefix(tc.GoVersion, "go")
+		if !version.IsValid(target) {
+			return fmt.Errorf("invalid goVersion %q", tc.GoVersion)
+		}
+	}
+	if !version.IsValid(target) || version.Compare(target, genericsVersion) >= 0 {
+		return nil
+	}
+	for _, typ := range tc.NewTypes() {
+		if isGeneric(typ) {
+			return fmt.Errorf("generic type %q requires %s, but goVersion is %s", typ, genericsVersion, target)
+		}
+	}
+	return nil
+}
+
+// NewTypes returns every type expression the document writes: field, map key,
+// channel element, parameter, result, method and var types.
+func (tc TypeConfig) NewTypes() []string {
+	var types []string
+	for _, m := range []map[string]string{tc.Fields, tc.Add, tc.MapKey, tc.ChanElem, tc.Methods, tc.Vars, tc.ReplaceTypes} {
+		types = append(types, slices.Collect(maps.Values(m))...)
+	}
+	types = append(types, slices.Collect(maps.Values(tc.Index))...)
+	for _, m := range []map[string]map[int]string{tc.FuncParams, tc.FuncResults} {
+		for _, byIndex := range m {
+			types = append(types, slices.Collect(maps.Values(byIndex))...)
+		}
+	}
+	return types
+}
+
+// isGeneric reports whether a type expression or method signature
+// instantiates a generic type.
+func isGeneric(typeStr string) bool {
+	expr, err := parser.ParseExpr(typeStr)
+	if err != nil {
+		expr, err = parser.ParseExpr("func" + typeStr)
+	}
+	if err != nil {
+		return false
+	}
+	var generic bool
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.IndexExpr, *ast.IndexListExpr:
+			generic = true
+		}
+		return !generic
+	})
+	return generic
+}
+
+// nameKeys marks plain mapping keys that YAML would read as null, such as a
+// field named Null, as strings, so they stay field names.
+func nameKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Kind == yaml.ScalarNode && key.Style == 0 && key.Tag == "!!null" && key.Value != "" && key.Value != "~" {
+				key.Tag = "!!str"
+			}
+		}
+	}
+	for _, child := range node.Content {
+		nameKeys(child)
+	}
+}
+
+// Write encodes configs as a multi-document YAML stream that Load reads back
+// to the same configs.
+func Write(w io.Writer, configs []TypeConfig) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	for _, cfg := range configs {
+		if err := encoder.Encode(cfg); err != nil {
+			return err
 		}
 	}
+	return encoder.Close()
+}
 
-	return configs, nil
+// IsPattern reports whether a fields key is a glob pattern such as "*" or
+// "*At" rather than a field name.
+func IsPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+func (tc TypeConfig) hasDirectives() bool {
+	return len(tc.Fields) > 0 || len(tc.Index) > 0 || len(tc.LineComments) > 0 || tc.TypeDoc != "" || len(tc.Methods) > 0 || tc.SortFields || len(tc.ChanDir) > 0 || len(tc.MapKey) > 0 || len(tc.ChanElem) > 0 || len(tc.FuncParams) > 0 || len(tc.FuncResults) > 0 || len(tc.EmbedPointer) > 0 || len(tc.UnwrapPointer) > 0 || len(tc.Tags) > 0 || len(tc.ToSlice) > 0 || len(tc.FromSlice) > 0 || len(tc.DropTag) > 0 || tc.RenameType != "" || len(tc.Rename) > 0 || len(tc.Add) > 0 || len(tc.Remove) > 0
 }
 
 func (tc TypeConfig) Imports() map[string]string {
-	imports := make(map[string]string)
+	var fieldTypes []string
 	for _, fieldType := range tc.Fields {
-		if pkg, alias, ok := parseQualifiedType(fieldType); ok {
-			imports[alias] = pkg
+		fieldTypes = append(fieldTypes, fieldType)
+	}
+	return tc.ImportsFor(fieldTypes...)
+}
+
+// ImportsFor returns the imports required by the given field types only.
+func (tc TypeConfig) ImportsFor(fieldTypes ...string) map[string]string {
+	imports := make(map[string]string)
+	for _, fieldType := range fieldTypes {
+		for _, alias := range PackageSelectors(fieldType) {
+			imports[alias], _ = tc.ImportPath(alias)
 		}
 	}
 	return imports
 }
 
+// ImportPath resolves the import path for a package selector. The second result
+// reports whether the path is known rather than guessed from the selector.
+func (tc TypeConfig) ImportPath(alias string) (string, bool) {
+	if path, ok := tc.ImportPaths[alias]; ok {
+		return path, true
+	}
+	if path, ok := stdlibPackages[alias]; ok {
+		return path, true
+	}
+	return alias, false
+}
+
+// UnknownPackages returns the selectors used by the given field types whose
+// import path could only be guessed.
+func (tc TypeConfig) UnknownPackages(fieldTypes ...string) []string {
+	var unknown []string
+	for _, fieldType := range fieldTypes {
+		for _, alias := range PackageSelectors(fieldType) {
+			if _, known := tc.ImportPath(alias); !known {
+				unknown = append(unknown, alias)
+			}
+		}
+	}
+	return unknown
+}
+
+// PackageSelectors returns the package selectors referenced by a type
+// expression or a method signature such as "(ctx context.Context) error".
+func PackageSelectors(typeStr string) []string {
+	expr, err := parser.ParseExpr(typeStr)
+	if err != nil {
+		expr, err = parser.ParseExpr("func" + typeStr)
+	}
+	if err != nil {
+		if _, alias, ok := parseQualifiedType(typeStr); ok {
+			return []string{alias}
+		}
+		return nil
+	}
+
+	var selectors []string
+	seen := make(map[string]bool)
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if _, ok := n.(*ast.CallExpr); ok {
+			// x.Method() is a value, not a type from package x.
+			return false
+		}
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if pkg, ok := sel.X.(*ast.Ident); ok && !seen[pkg.Name] {
+			seen[pkg.Name] = true
+			selectors = append(selectors, pkg.Name)
+		}
+		return false
+	})
+	return selectors
+}
+
+// LocalTypes returns the unqualified type names a type expression refers to,
+// such as status in "[]status", leaving out predeclared types like int.
+func LocalTypes(typeStr string) []string {
+	expr, err := parser.ParseExpr(typeStr)
+	if err != nil {
+		expr, err = parser.ParseExpr("func" + typeStr)
+	}
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr, *ast.CallExpr:
+			return false
+		case *ast.Field:
+			// Skip parameter, field and method names.
+			ast.Inspect(n.Type, visit)
+			return false
+		case *ast.ArrayType:
+			// The length is a constant, not a type.
+			ast.Inspect(n.Elt, visit)
+			return false
+		case *ast.Ident:
+			if _, predeclared := types.Universe.Lookup(n.Name).(*types.TypeName); !predeclared && !seen[n.Name] {
+				seen[n.Name] = true
+				names = append(names, n.Name)
+			}
+		}
+		return true
+	}
+	ast.Inspect(expr, visit)
+	return names
+}
+
 func parseQualifiedType(typeStr string) (pkg string, alias string, ok bool) {
 	typeStr = strings.TrimPrefix(typeStr, "*")
 	parts := strings.SplitN(typeStr, ".", 2)
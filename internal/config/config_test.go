@@ -1,6 +1,7 @@
 package config
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
@@ -63,6 +64,303 @@ fields:
 		assert.Equal(t, "WithFields", configs[0].Type)
 	})
 
+	t.Run("line comments only", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		err := os.WriteFile(configPath, []byte(`type: Example
+lineComments:
+  Total: deprecated
+`), 0644)
+		require.NoError(t, err)
+
+		configs, err := Load(configPath)
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, map[string]string{"Total": "deprecated"}, configs[0].LineComments)
+	})
+
+	t.Run("vars without type", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		err := os.WriteFile(configPath, []byte(`vars:
+  MaxSize: int64
+`), 0644)
+		require.NoError(t, err)
+
+		configs, err := Load(configPath)
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, map[string]string{"MaxSize": "int64"}, configs[0].Vars)
+	})
+
+	t.Run("when guard", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		err := os.WriteFile(configPath, []byte(`type: Row
+when:
+  imports: ["database/sql"]
+fields:
+  Name: sql.NullString
+`), 0644)
+		require.NoError(t, err)
+
+		configs, err := Load(configPath)
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, []string{"database/sql"}, configs[0].When.Imports)
+		assert.True(t, configs[0].When.Matches([]string{"fmt", "database/sql"}))
+		assert.False(t, configs[0].When.Matches([]string{"fmt"}))
+		assert.True(t, Condition{}.Matches(nil))
+	})
+
+	t.Run("merge keys across documents", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		err := os.WriteFile(configPath, []byte(`common: &common
+  Total: uint64
+  CreatedAt: time.Time
+imports: &imports
+  uuid: github.com/google/uuid
+---
+type: Order
+fields:
+  <<: *common
+  ID: uuid.UUID
+imports: *imports
+---
+type: Invoice
+fields:
+  <<: *common
+  Total: float64
+`), 0644)
+		require.NoError(t, err)
+
+		configs, err := Load(configPath)
+		require.NoError(t, err)
+		require.Len(t, configs, 2)
+		assert.Equal(t, map[string]string{"Total": "uint64", "CreatedAt": "time.Time", "ID": "uuid.UUID"}, configs[0].Fields)
+		assert.Equal(t, map[string]string{"uuid": "github.com/google/uuid"}, configs[0].ImportPaths)
+		assert.Equal(t, map[string]string{"Total": "float64", "CreatedAt": "time.Time"}, configs[1].Fields)
+	})
+
+	t.Run("unknown anchor", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		err := os.WriteFile(configPath, []byte(`type: Order
+fields:
+  <<: *missing
+`), 0644)
+		require.NoError(t, err)
+
+		_, err = Load(configPath)
+		require.ErrorIs(t, err, ErrParse)
+	})
+
+	t.Run("byte order mark and CRLF", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		content := "\ufefftype: Example\r\nfields:\r\n  Total: uint64\r\ntypeDoc: |\r\n  Example is a sample.\r\n---\r\ntype: Order\r\nfields:\r\n  CreatedAt: time.Time\r\n"
+		err := os.WriteFile(configPath, []byte(content), 0644)
+		require.NoError(t, err)
+
+		configs, err := Load(configPath)
+		require.NoError(t, err)
+		require.Len(t, configs, 2)
+		assert.Equal(t, "Example", configs[0].Type)
+		assert.Equal(t, map[string]string{"Total": "uint64"}, configs[0].Fields)
+		assert.Equal(t, "Example is a sample.\n", configs[0].TypeDoc)
+		assert.Equal(t, map[string]string{"CreatedAt": "time.Time"}, configs[1].Fields)
+	})
+
+	t.Run("warnings for ignored documents", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		err := os.WriteFile(configPath, []byte(`common: &common
+  Total: uint64
+---
+type: OnlyType
+---
+fields:
+  Foo: string
+---
+type: Example
+fields: *common
+`), 0644)
+		require.NoError(t, err)
+
+		configs, warnings, err := LoadWithWarnings(configPath)
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, []string{
+			"document 2 (type OnlyType) has no directives and is ignored",
+			"document 3 has directives but no type and is ignored",
+		}, warnings)
+	})
+
+	t.Run("global imports", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		err := os.WriteFile(configPath, []byte(`imports:
+  uuid: github.com/google/uuid
+  decimal: github.com/shopspring/decimal
+---
+type: Order
+fields:
+  ID: uuid.UUID
+  Total: decimal.Decimal
+---
+type: Invoice
+imports:
+  uuid: github.com/gofrs/uuid
+fields:
+  ID: uuid.UUID
+`), 0644)
+		require.NoError(t, err)
+
+		configs, warnings, err := LoadWithWarnings(configPath)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+		require.Len(t, configs, 2)
+		assert.Equal(t, map[string]string{
+			"uuid":    "github.com/google/uuid",
+			"decimal": "github.com/shopspring/decimal",
+		}, configs[0].Imports())
+		assert.Equal(t, map[string]string{"uuid": "github.com/gofrs/uuid"}, configs[1].Imports())
+		assert.Equal(t, "github.com/shopspring/decimal", configs[1].ImportPaths["decimal"])
+	})
+
+	t.Run("field names YAML would read as other values", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		err := os.WriteFile(configPath, []byte(`type: Row
+fields:
+  Null: sql.NullString
+  NULL: int
+  True: bool
+  Off: bool
+  Größe: int64
+  _private: string
+lineComments:
+  Null: may be empty
+`), 0644)
+		require.NoError(t, err)
+
+		configs, err := Load(configPath)
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, map[string]string{
+			"Null":     "sql.NullString",
+			"NULL":     "int",
+			"True":     "bool",
+			"Off":      "bool",
+			"Größe":    "int64",
+			"_private": "string",
+		}, configs[0].Fields)
+		assert.Equal(t, map[string]string{"Null": "may be empty"}, configs[0].LineComments)
+	})
+
+	t.Run("index", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		err := os.WriteFile(configPath, []byte(`type: Generated
+index:
+  0: int64
+  2: time.Time
+`), 0644)
+		require.NoError(t, err)
+
+		configs, err := Load(configPath)
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, map[int]string{0: "int64", 2: "time.Time"}, configs[0].Index)
+	})
+
+	t.Run("goVersion", func(t *testing.T) {
+		load := func(t *testing.T, content string) ([]TypeConfig, error) {
+			configPath := filepath.Join(t.TempDir(), "edit.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(content), 0644))
+			return Load(configPath)
+		}
+
+		configs, err := load(t, `type: Order
+fields:
+  Items: opt.Optional[string]
+`)
+		require.NoError(t, err)
+		assert.Empty(t, configs[0].GoVersion)
+
+		_, err = load(t, `goVersion: "1.17"
+---
+type: Order
+fields:
+  Total: int64
+  Items: opt.Optional[string]
+`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `document 2: generic type "opt.Optional[string]" requires go1.18, but goVersion is go1.17`)
+
+		configs, err = load(t, `goVersion: go1.17
+---
+type: Order
+goVersion: "1.21"
+fields:
+  Items: opt.Optional[string]
+---
+type: Invoice
+fields:
+  Lines: "[8]Line"
+`)
+		require.NoError(t, err)
+		assert.Equal(t, "1.21", configs[0].GoVersion)
+		assert.Equal(t, "go1.17", configs[1].GoVersion)
+
+		_, err = load(t, `type: Order
+goVersion: latest
+fields:
+  Total: int64
+`)
+		assert.ErrorContains(t, err, `invalid goVersion "latest"`)
+	})
+
+	t.Run("renameType", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), "edit.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte("type: Old\nrenameType: New\n---\ntype: Other\nrenameType: not-a-name\n"), 0644))
+
+		_, err := Load(configPath)
+		assert.EqualError(t, err, `document 2: invalid renameType "not-a-name"`)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.json")
+		err := os.WriteFile(configPath, []byte(`[
+  {"imports": {"uuid": "github.com/google/uuid"}},
+  {"type": "Order", "fields": {"ID": "uuid.UUID", "Total": "*int64"}, "index": {"2": "string"}},
+  {"type": "Empty"}
+]`), 0644)
+		require.NoError(t, err)
+
+		configs, warnings, err := LoadWithWarnings(configPath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"document 3 (type Empty) has no directives and is ignored"}, warnings)
+		require.Len(t, configs, 1)
+		assert.Equal(t, TypeConfig{
+			Type:        "Order",
+			Fields:      map[string]string{"ID": "uuid.UUID", "Total": "*int64"},
+			Index:       map[int]string{2: "string"},
+			ImportPaths: map[string]string{"uuid": "github.com/google/uuid"},
+		}, configs[0])
+
+		require.NoError(t, os.WriteFile(configPath, []byte(`{"type": "Order", "fields": {"ID": "string"}}`), 0644))
+		_, err = Load(configPath)
+		assert.ErrorIs(t, err, ErrParse)
+		assert.EqualError(t, err, "parse config: JSON config must be an array of objects")
+
+		require.NoError(t, os.WriteFile(configPath, []byte(`[{"type": "Order",}]`), 0644))
+		_, err = Load(configPath)
+		assert.ErrorIs(t, err, ErrParse)
+	})
+
 	t.Run("file not found", func(t *testing.T) {
 		_, err := Load("/nonexistent/path.yaml")
 		require.Error(t, err)
@@ -79,9 +377,45 @@ fields: [invalid`), 0644)
 		_, err = Load(configPath)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "parse config")
+		assert.ErrorIs(t, err, ErrParse)
 	})
 }
 
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "edit.yaml")
+	err := os.WriteFile(configPath, []byte(`imports:
+  uuid: github.com/google/uuid
+---
+type: Order
+index:
+  1: int64
+fields:
+  ID: uuid.UUID
+funcParams:
+  Handler: {0: context.Context}
+when:
+  imports: [database/sql]
+---
+vars:
+  MaxSize: int64
+`), 0644)
+	require.NoError(t, err)
+
+	configs, err := Load(configPath)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, configs))
+	assert.NotContains(t, buf.String(), "sortFields")
+
+	dumped := filepath.Join(dir, "dumped.yaml")
+	require.NoError(t, os.WriteFile(dumped, buf.Bytes(), 0644))
+	reloaded, err := Load(dumped)
+	require.NoError(t, err)
+	assert.Equal(t, configs, reloaded)
+}
+
 func TestTypeConfig_Imports(t *testing.T) {
 	t.Run("no qualified types", func(t *testing.T) {
 		tc := TypeConfig{
@@ -128,6 +462,84 @@ func TestTypeConfig_Imports(t *testing.T) {
 	})
 }
 
+func TestTypeConfig_Imports_Stdlib(t *testing.T) {
+	t.Run("multi-segment path", func(t *testing.T) {
+		tc := TypeConfig{
+			Type:   "Example",
+			Fields: map[string]string{"Client": "*http.Client"},
+		}
+		assert.Equal(t, map[string]string{"http": "net/http"}, tc.Imports())
+	})
+
+	t.Run("rand", func(t *testing.T) {
+		tc := TypeConfig{
+			Type:   "Example",
+			Fields: map[string]string{"Source": "*rand.Rand"},
+		}
+		assert.Equal(t, map[string]string{"rand": "math/rand"}, tc.Imports())
+	})
+
+	t.Run("single-segment path", func(t *testing.T) {
+		tc := TypeConfig{
+			Type:   "Example",
+			Fields: map[string]string{"Buf": "bytes.Buffer"},
+		}
+		assert.Equal(t, map[string]string{"bytes": "bytes"}, tc.Imports())
+	})
+
+	t.Run("unknown selector is guessed", func(t *testing.T) {
+		tc := TypeConfig{Type: "Example"}
+		path, known := tc.ImportPath("uuid")
+		assert.False(t, known)
+		assert.Equal(t, "uuid", path)
+	})
+}
+
+func TestTypeConfig_ImportPaths(t *testing.T) {
+	t.Run("per-type paths", func(t *testing.T) {
+		first := TypeConfig{
+			Type:        "First",
+			Fields:      map[string]string{"ID": "uuid.UUID"},
+			ImportPaths: map[string]string{"uuid": "example.com/a/uuid"},
+		}
+		second := TypeConfig{
+			Type:        "Second",
+			Fields:      map[string]string{"ID": "uuid.UUID"},
+			ImportPaths: map[string]string{"uuid": "example.com/b/uuid"},
+		}
+		assert.Equal(t, map[string]string{"uuid": "example.com/a/uuid"}, first.Imports())
+		assert.Equal(t, map[string]string{"uuid": "example.com/b/uuid"}, second.Imports())
+		assert.Empty(t, first.UnknownPackages("uuid.UUID"))
+	})
+
+	t.Run("overrides standard library", func(t *testing.T) {
+		tc := TypeConfig{
+			Type:        "Example",
+			ImportPaths: map[string]string{"rand": "crypto/rand"},
+		}
+		path, known := tc.ImportPath("rand")
+		assert.True(t, known)
+		assert.Equal(t, "crypto/rand", path)
+	})
+
+	t.Run("load from yaml", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "edit.yaml")
+		err := os.WriteFile(configPath, []byte(`type: Example
+fields:
+  ID: uuid.UUID
+imports:
+  uuid: github.com/google/uuid
+`), 0644)
+		require.NoError(t, err)
+
+		configs, err := Load(configPath)
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, map[string]string{"uuid": "github.com/google/uuid"}, configs[0].Imports())
+	})
+}
+
 func TestParseQualifiedType(t *testing.T) {
 	t.Run("built-in type", func(t *testing.T) {
 		pkg, alias, ok := parseQualifiedType("int64")
@@ -157,3 +569,52 @@ func TestParseQualifiedType(t *testing.T) {
 		assert.Equal(t, "uuid", alias)
 	})
 }
+
+func TestTypeConfig_ImportsFor(t *testing.T) {
+	tc := TypeConfig{
+		Type: "Example",
+		Fields: map[string]string{
+			"CreatedAt": "time.Time",
+			"ID":        "uuid.UUID",
+		},
+	}
+	assert.Equal(t, map[string]string{"time": "time"}, tc.ImportsFor("time.Time", "int64"))
+	assert.Empty(t, tc.ImportsFor())
+}
+
+func TestTypeConfig_UnknownPackages(t *testing.T) {
+	tc := TypeConfig{Type: "Example"}
+	assert.Empty(t, tc.UnknownPackages("time.Time", "*context.Context", "int64"))
+	assert.Equal(t, []string{"pb"}, tc.UnknownPackages("pb.Message", "string"))
+}
+
+func TestPackageSelectors(t *testing.T) {
+	assert.Empty(t, PackageSelectors("int64"))
+	assert.Equal(t, []string{"time"}, PackageSelectors("*time.Time"))
+	assert.Equal(t, []string{"uuid", "time"}, PackageSelectors("map[uuid.UUID][]time.Time"))
+	assert.Equal(t, []string{"context", "io"}, PackageSelectors("(ctx context.Context, r io.Reader) error"))
+	assert.Equal(t, []string{"time"}, PackageSelectors("Map[string, time.Time]"))
+	assert.Empty(t, PackageSelectors("map[OrderStatus][]*Order"))
+	assert.Empty(t, PackageSelectors("status.String()"))
+	assert.Equal(t, []string{"cache", "uuid", "time"}, PackageSelectors("cache.Map[uuid.UUID, *time.Time]"))
+	assert.Equal(t, []string{"pkg", "uuid"}, PackageSelectors("[]*pkg.Map[uuid.UUID, *pkg.List[int64]]"))
+}
+
+func TestLocalTypes(t *testing.T) {
+	assert.Empty(t, LocalTypes("int64"))
+	assert.Empty(t, LocalTypes("*time.Time"))
+	assert.Equal(t, []string{"status"}, LocalTypes("[]status"))
+	assert.Equal(t, []string{"OrderStatus", "Order"}, LocalTypes("map[OrderStatus][]*Order"))
+	assert.Equal(t, []string{"Line"}, LocalTypes("[size]Line"))
+	assert.Equal(t, []string{"Request"}, LocalTypes("(ctx context.Context, req Request) error"))
+	assert.Equal(t, []string{"Set", "key"}, LocalTypes("Set[key, any]"))
+	assert.Empty(t, LocalTypes("struct{ Name string }"))
+}
+
+func TestFieldCondition_Matches(t *testing.T) {
+	assert.True(t, FieldCondition{}.Matches(""))
+	assert.True(t, FieldCondition{Tag: "db"}.Matches(`db:"name"`))
+	assert.True(t, FieldCondition{Tag: "db", Contains: "nullable"}.Matches(`json:"name" db:"name,nullable"`))
+	assert.False(t, FieldCondition{Tag: "db", Contains: "nullable"}.Matches(`json:"name,nullable" db:"name"`))
+	assert.False(t, FieldCondition{Tag: "db"}.Matches(`json:"name"`))
+}
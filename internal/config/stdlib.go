@@ -0,0 +1,37 @@
+package config
+
+var stdlibPackages = map[string]string{
+	"atomic":   "sync/atomic",
+	"base64":   "encoding/base64",
+	"big":      "math/big",
+	"bufio":    "bufio",
+	"bytes":    "bytes",
+	"context":  "context",
+	"errors":   "errors",
+	"filepath": "path/filepath",
+	"fmt":      "fmt",
+	"fs":       "io/fs",
+	"hex":      "encoding/hex",
+	"http":     "net/http",
+	"io":       "io",
+	"json":     "encoding/json",
+	"math":     "math",
+	"net":      "net",
+	"netip":    "net/netip",
+	"os":       "os",
+	"rand":     "math/rand",
+	"reflect":  "reflect",
+	"regexp":   "regexp",
+	"slog":     "log/slog",
+	"sort":     "sort",
+	"sql":      "database/sql",
+	"strconv":  "strconv",
+	"strings":  "strings",
+	"sync":     "sync",
+	"template": "text/template",
+	"time":     "time",
+	"unicode":  "unicode",
+	"url":      "net/url",
+	"utf8":     "unicode/utf8",
+	"xml":      "encoding/xml",
+}
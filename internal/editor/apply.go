@@ -0,0 +1,381 @@
+package editor
+
+import (
+	"fmt"
+	"go/ast"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+// SetNoImports stops ApplyConfig from adding imports for the qualified types
+// it writes, e.g. when goimports runs afterwards.
+func (e *Editor) SetNoImports(noImports bool) {
+	e.noImports = noImports
+}
+
+// ApplyConfig applies every directive of a config document to the file: the
+// type's fields, methods, map keys, embeddings, doc and comments, the document's vars, and
+// field sorting. Edits are applied immediately and the imports needed by the
+// new types are added. It returns the field edits that were made.
+func (e *Editor) ApplyConfig(tc config.TypeConfig) ([]FieldEdit, error) {
+	var applied []FieldEdit
+	var uses []TypeUse
+	useField := func(structName, field, typ string) {
+		uses = append(uses, TypeUse{Kind: "field", Subject: structName + "." + field, Type: typ})
+	}
+
+	if name := tc.Type; name != "" && (e.strict || len(e.typeDecls(name)) > 0) {
+		// Fields are renamed first, so the other directives use the new names.
+		for _, oldName := range slices.Sorted(maps.Keys(tc.Rename)) {
+			if _, err := e.RenameField(name, oldName, tc.Rename[oldName]); err != nil {
+				return nil, fmt.Errorf("rename %s.%s: %w", name, oldName, err)
+			}
+		}
+		// Missing fields are added next, so other directives can refine them.
+		for _, field := range slices.Sorted(maps.Keys(tc.Add)) {
+			ok, err := e.AddField(name, field, tc.Add[field], "")
+			if err != nil {
+				return nil, fmt.Errorf("add field %s.%s: %w", name, field, err)
+			}
+			if ok {
+				useField(name, field, tc.Add[field])
+			}
+		}
+		for _, field := range tc.Remove {
+			if _, err := e.RemoveField(name, field); err != nil {
+				return nil, fmt.Errorf("remove field %s.%s: %w", name, field, err)
+			}
+			// One at a time, as names of one group may be removed together.
+			if err := e.Apply(); err != nil {
+				return nil, fmt.Errorf("apply: %w", err)
+			}
+		}
+		if err := e.Apply(); err != nil {
+			return nil, fmt.Errorf("apply: %w", err)
+		}
+
+		fieldEdits, err := e.resolveFields(name, tc)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := e.EditStructDetailed(name, fieldEdits)
+		if err != nil {
+			return nil, fmt.Errorf("edit struct %s: %w", name, err)
+		}
+		applied = fields
+		for _, fe := range fields {
+			useField(fe.Struct, fe.Field, fe.NewType)
+		}
+
+		if len(tc.Methods) > 0 {
+			ok, err := e.EditInterface(name, tc.Methods)
+			if err != nil {
+				return nil, fmt.Errorf("edit interface %s: %w", name, err)
+			}
+			if ok {
+				for _, method := range slices.Sorted(maps.Keys(tc.Methods)) {
+					uses = append(uses, TypeUse{Kind: "method", Subject: name + "." + method, Type: tc.Methods[method]})
+				}
+			}
+		}
+
+		for _, field := range slices.Sorted(maps.Keys(tc.MapKey)) {
+			keyType := tc.MapKey[field]
+			ok, err := e.SetMapKey(name, field, keyType)
+			if err != nil {
+				return nil, fmt.Errorf("set map key %s.%s: %w", name, field, err)
+			}
+			if ok {
+				useField(name, field, keyType)
+			}
+		}
+
+		for _, field := range slices.Sorted(maps.Keys(tc.ChanElem)) {
+			elemType := tc.ChanElem[field]
+			ok, err := e.SetChanElem(name, field, elemType)
+			if err != nil {
+				return nil, fmt.Errorf("set channel element %s.%s: %w", name, field, err)
+			}
+			if ok {
+				useField(name, field, elemType)
+			}
+		}
+
+		for _, field := range slices.Sorted(maps.Keys(tc.FuncParams)) {
+			params := tc.FuncParams[field]
+			for _, index := range slices.Sorted(maps.Keys(params)) {
+				typ := params[index]
+				ok, err := e.SetFuncParam(name, field, index, typ)
+				if err != nil {
+					return nil, fmt.Errorf("set parameter %s.%s: %w", name, field, err)
+				}
+				if ok {
+					useField(name, field, typ)
+				}
+			}
+		}
+
+		for _, field := range slices.Sorted(maps.Keys(tc.FuncResults)) {
+			results := tc.FuncResults[field]
+			for _, index := range slices.Sorted(maps.Keys(results)) {
+				typ := results[index]
+				ok, err := e.SetFuncResult(name, field, index, typ)
+				if err != nil {
+					return nil, fmt.Errorf("set result %s.%s: %w", name, field, err)
+				}
+				if ok {
+					useField(name, field, typ)
+				}
+			}
+		}
+
+		for _, field := range slices.Sorted(maps.Keys(tc.UnwrapPointer)) {
+			if _, err := e.UnwrapPointer(name, field, tc.UnwrapPointer[field]); err != nil {
+				return nil, fmt.Errorf("unwrap %s.%s: %w", name, field, err)
+			}
+		}
+
+		for _, field := range tc.ToSlice {
+			if _, err := e.SetSlice(name, field, true); err != nil {
+				return nil, fmt.Errorf("convert %s.%s to slice: %w", name, field, err)
+			}
+		}
+		for _, field := range tc.FromSlice {
+			if _, err := e.SetSlice(name, field, false); err != nil {
+				return nil, fmt.Errorf("convert %s.%s from slice: %w", name, field, err)
+			}
+		}
+
+		setTags := e.ReplaceTags
+		if tc.MergeTags {
+			setTags = e.SetTags
+		}
+		for _, field := range slices.Sorted(maps.Keys(tc.Tags)) {
+			if _, err := setTags(name, field, tc.Tags[field]); err != nil {
+				return nil, fmt.Errorf("set tags %s.%s: %w", name, field, err)
+			}
+		}
+
+		for _, field := range tc.DropTag {
+			if _, err := e.DropTag(name, field); err != nil {
+				return nil, fmt.Errorf("drop tag %s.%s: %w", name, field, err)
+			}
+		}
+
+		for _, typeName := range slices.Sorted(maps.Keys(tc.EmbedPointer)) {
+			if _, err := e.SetEmbeddedPointer(name, typeName, tc.EmbedPointer[typeName]); err != nil {
+				return nil, fmt.Errorf("set embedding %s.%s: %w", name, typeName, err)
+			}
+		}
+
+		if tc.TypeDoc != "" {
+			if _, err := e.SetTypeDoc(name, tc.TypeDoc); err != nil {
+				return nil, fmt.Errorf("set doc %s: %w", name, err)
+			}
+		}
+
+		for _, field := range slices.Sorted(maps.Keys(tc.ChanDir)) {
+			if _, err := e.SetChanDir(name, field, tc.ChanDir[field]); err != nil {
+				return nil, fmt.Errorf("set channel direction %s.%s: %w", name, field, err)
+			}
+		}
+
+		for _, field := range slices.Sorted(maps.Keys(tc.LineComments)) {
+			if _, err := e.SetLineComment(name, field, tc.LineComments[field]); err != nil {
+				return nil, fmt.Errorf("set comment %s.%s: %w", name, field, err)
+			}
+		}
+	}
+
+	if len(tc.ReplaceTypes) > 0 {
+		replaced := e.ReplaceTypes(tc.ReplaceTypes)
+		applied = append(applied, replaced...)
+		for _, fe := range replaced {
+			useField(fe.Struct, fe.Field, fe.NewType)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(tc.Vars)) {
+		typ := tc.Vars[name]
+		ok, err := e.SetValueType(name, typ)
+		if err != nil {
+			return nil, fmt.Errorf("set type of %s: %w", name, err)
+		}
+		if ok {
+			uses = append(uses, TypeUse{Kind: "var", Subject: name, Type: typ})
+		}
+	}
+
+	if err := e.Apply(); err != nil {
+		return nil, fmt.Errorf("apply: %w", err)
+	}
+
+	if tc.SortFields && tc.Type != "" {
+		if _, err := e.SortFields(tc.Type); err != nil {
+			return nil, fmt.Errorf("sort fields %s: %w", tc.Type, err)
+		}
+		if err := e.Apply(); err != nil {
+			return nil, fmt.Errorf("apply: %w", err)
+		}
+	}
+
+	e.typeUses = append(e.typeUses, uses...)
+	newTypes := make([]string, len(uses))
+	for i, use := range uses {
+		newTypes[i] = use.Type
+	}
+	if imports := tc.ImportsFor(newTypes...); len(imports) > 0 && !e.noImports {
+		if err := e.AddImports(imports); err != nil {
+			return nil, fmt.Errorf("add imports: %w", err)
+		}
+	}
+	return applied, nil
+}
+
+// FieldDecision describes what ApplyConfig does with one field of a struct.
+type FieldDecision struct {
+	Struct   string
+	Field    string
+	Key      string // fields or index entry that matched, empty if none
+	OldType  string
+	NewType  string
+	Decision string // changed, unchanged, not-configured or skipped-embedded
+}
+
+// Explain describes, for every top-level field of the document's type, whether
+// the document's fields and index entries change it. It must be called before
+// ApplyConfig, as it reports the current types.
+func (e *Editor) Explain(tc config.TypeConfig) ([]FieldDecision, error) {
+	if tc.Type == "" {
+		return nil, nil
+	}
+	fieldEdits, err := e.resolveFields(tc.Type, tc)
+	if err != nil {
+		return nil, err
+	}
+
+	var decisions []FieldDecision
+	for _, st := range e.structTypes(tc.Type) {
+		for _, field := range st.Fields.List {
+			names := field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{ast.NewIdent(embeddedName(field.Type))}
+			}
+			for _, name := range names {
+				d := FieldDecision{Struct: tc.Type, Field: name.Name, OldType: e.typeString(field.Type)}
+				newType, ok := fieldEdits[name.Name]
+				switch {
+				case !ok && len(field.Names) == 0:
+					d.Decision = "skipped-embedded"
+				case !ok:
+					d.Decision = "not-configured"
+				case normalizeType(newType) == d.OldType:
+					d.Key, d.NewType, d.Decision = e.matchedKey(tc, name.Name), normalizeType(newType), "unchanged"
+				default:
+					d.Key, d.NewType, d.Decision = e.matchedKey(tc, name.Name), normalizeType(newType), "changed"
+				}
+				decisions = append(decisions, d)
+			}
+		}
+	}
+	return decisions, nil
+}
+
+// matchedKey returns the fields key or index that resolveFields used for a
+// field, following its precedence.
+func (e *Editor) matchedKey(tc config.TypeConfig, field string) string {
+	if _, ok := tc.Fields[field]; ok {
+		return field
+	}
+	for _, index := range slices.Sorted(maps.Keys(tc.Index)) {
+		if name, ok := e.FieldAt(tc.Type, index); ok && name == field {
+			return "index " + strconv.Itoa(index)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(tc.Fields)) {
+		if ok, _ := path.Match(key, field); ok && config.IsPattern(key) {
+			return key
+		}
+	}
+	return ""
+}
+
+// resolveFields expands the fields map for a type: glob patterns match the
+// type's top-level named fields, the index directive names fields by position,
+// and fields listed in except or failing the where guard are dropped. Fields
+// named explicitly take precedence over patterns and indexes.
+func (e *Editor) resolveFields(name string, tc config.TypeConfig) (map[string]string, error) {
+	if len(tc.Index) == 0 && len(tc.Except) == 0 && tc.Where.Tag == "" && !slices.ContainsFunc(slices.Collect(maps.Keys(tc.Fields)), config.IsPattern) {
+		return tc.Fields, nil
+	}
+
+	fields := make(map[string]string, len(tc.Fields)+len(tc.Index))
+	var patterns []string
+	for key, typ := range tc.Fields {
+		if config.IsPattern(key) {
+			patterns = append(patterns, key)
+		} else {
+			fields[key] = typ
+		}
+	}
+	slices.Sort(patterns)
+
+	resolved := make(map[string]string)
+	for _, index := range slices.Sorted(maps.Keys(tc.Index)) {
+		field, ok := e.FieldAt(name, index)
+		if !ok {
+			if e.strict && len(e.structTypes(name)) > 0 {
+				return nil, fmt.Errorf("%w: %s field index %d is out of range", ErrFieldNotFound, name, index)
+			}
+			continue
+		}
+		resolved[field] = tc.Index[index]
+	}
+	for _, field := range e.FieldNames(name) {
+		for _, pattern := range patterns {
+			if ok, err := path.Match(pattern, field); err != nil {
+				return nil, fmt.Errorf("field pattern %q: %w", pattern, err)
+			} else if ok {
+				if _, set := resolved[field]; !set {
+					resolved[field] = tc.Fields[pattern]
+				}
+			}
+		}
+	}
+	for field, typ := range resolved {
+		if _, explicit := fields[field]; !explicit {
+			fields[field] = typ
+		}
+	}
+
+	for _, field := range tc.Except {
+		delete(fields, field)
+	}
+	if tc.Where.Tag != "" {
+		for field := range fields {
+			if !e.fieldMatches(name, field, tc.Where) {
+				delete(fields, field)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// fieldMatches reports whether a field of the type satisfies a where guard.
+func (e *Editor) fieldMatches(structName, fieldName string, where config.FieldCondition) bool {
+	for _, st := range e.structTypes(structName) {
+		field := lookupField(st, fieldName)
+		if field == nil || field.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err == nil && where.Matches(tag) {
+			return true
+		}
+	}
+	return false
+}
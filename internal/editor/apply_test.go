@@ -0,0 +1,332 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+func TestEditor_ApplyConfig(t *testing.T) {
+	original := `package test
+
+var Timeout int64
+
+type Example struct {
+	Total     *int64
+	CreatedAt string
+	Index     map[string]int
+}
+`
+	parse := func(t *testing.T) *Editor {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		return ed
+	}
+
+	t.Run("all directives", func(t *testing.T) {
+		ed := parse(t)
+
+		applied, err := ed.ApplyConfig(config.TypeConfig{
+			Type:         "Example",
+			Fields:       map[string]string{"Total": "uint64", "CreatedAt": "time.Time"},
+			MapKey:       map[string]string{"Index": "uuid.UUID"},
+			TypeDoc:      "Example is a sample.",
+			LineComments: map[string]string{"Total": "in cents"},
+			SortFields:   true,
+			Vars:         map[string]string{"Timeout": "time.Duration"},
+			ImportPaths:  map[string]string{"uuid": "github.com/google/uuid"},
+		})
+		require.NoError(t, err)
+		assert.Len(t, applied, 2)
+		assert.True(t, ed.Modified())
+		assert.Equal(t, map[string]string{"time": "time", "uuid": "github.com/google/uuid"}, ed.AddedImports())
+
+		src := string(ed.Source())
+		assert.Contains(t, src, "\t\"github.com/google/uuid\"\n")
+		assert.Contains(t, src, "\t\"time\"\n")
+		assert.Equal(t, `var Timeout time.Duration
+
+// Example is a sample.
+type Example struct {
+	CreatedAt time.Time
+	Index     map[uuid.UUID]int
+	Total     uint64 // in cents
+}
+`, src[strings.Index(src, "var Timeout"):])
+	})
+
+	t.Run("no imports", func(t *testing.T) {
+		ed := parse(t)
+		ed.SetNoImports(true)
+
+		_, err := ed.ApplyConfig(config.TypeConfig{Type: "Example", Fields: map[string]string{"CreatedAt": "time.Time"}})
+		require.NoError(t, err)
+		assert.Empty(t, ed.AddedImports())
+		assert.NotContains(t, string(ed.Source()), "import")
+	})
+
+	t.Run("absent type", func(t *testing.T) {
+		ed := parse(t)
+
+		applied, err := ed.ApplyConfig(config.TypeConfig{Type: "Other", Fields: map[string]string{"Total": "uint64"}})
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.False(t, ed.Modified())
+
+		ed.SetStrict(true)
+		_, err = ed.ApplyConfig(config.TypeConfig{Type: "Other", Fields: map[string]string{"Total": "uint64"}})
+		assert.ErrorIs(t, err, ErrStructNotFound)
+	})
+
+	t.Run("rename before retyping", func(t *testing.T) {
+		ed := parse(t)
+		ed.SetStrict(true)
+
+		applied, err := ed.ApplyConfig(config.TypeConfig{
+			Type:   "Example",
+			Rename: map[string]string{"CreatedAt": "Created"},
+			Fields: map[string]string{"Created": "time.Time"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []FieldEdit{
+			{Struct: "Example", Field: "Created", OldType: "string", NewType: "time.Time"},
+		}, applied)
+		assert.Contains(t, string(ed.Source()), "\tCreated time.Time\n")
+	})
+
+	t.Run("add fields", func(t *testing.T) {
+		ed := parse(t)
+
+		_, err := ed.ApplyConfig(config.TypeConfig{
+			Type:        "Example",
+			Add:         map[string]string{"ID": "uuid.UUID", "Total": "string"},
+			Tags:        map[string]map[string]string{"ID": {"json": "id"}},
+			ImportPaths: map[string]string{"uuid": "github.com/google/uuid"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"uuid": "github.com/google/uuid"}, ed.AddedImports())
+		src := string(ed.Source())
+		assert.Contains(t, src, "\tIndex     map[string]int\n\tID        uuid.UUID `json:\"id\"`\n}")
+		assert.Contains(t, src, "\tTotal     *int64\n")
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		src := "package test\n\ntype Example struct {\n\tID string `db:\"id\" json:\"id\"`\n}\n"
+		require.NoError(t, os.WriteFile(filePath, []byte(src), 0644))
+		tags := map[string]map[string]string{"ID": {"json": "id,omitempty"}}
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		_, err = ed.ApplyConfig(config.TypeConfig{Type: "Example", Tags: tags})
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\ntype Example struct {\n\tID string `json:\"id,omitempty\"`\n}\n", string(ed.Source()))
+
+		ed, err = ParseFile(filePath)
+		require.NoError(t, err)
+		_, err = ed.ApplyConfig(config.TypeConfig{Type: "Example", Tags: tags, MergeTags: true})
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\ntype Example struct {\n\tID string `db:\"id\" json:\"id,omitempty\"`\n}\n", string(ed.Source()))
+	})
+
+	t.Run("remove fields", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\ntype Example struct {\n\tA, B, C int\n\tName    string\n}\n"), 0644))
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		_, err = ed.ApplyConfig(config.TypeConfig{Type: "Example", Remove: []string{"A", "B", "C", "Missing"}})
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\ntype Example struct {\n\tName string\n}\n", string(ed.Source()))
+	})
+
+	t.Run("index", func(t *testing.T) {
+		ed := parse(t)
+
+		applied, err := ed.ApplyConfig(config.TypeConfig{
+			Type:   "Example",
+			Fields: map[string]string{"Total": "int32"},
+			Index:  map[int]string{0: "uint64", 1: "time.Time", 7: "int"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []FieldEdit{
+			{Struct: "Example", Field: "Total", OldType: "*int64", NewType: "int32"},
+			{Struct: "Example", Field: "CreatedAt", OldType: "string", NewType: "time.Time"},
+		}, applied)
+
+		ed = parse(t)
+		ed.SetStrict(true)
+		_, err = ed.ApplyConfig(config.TypeConfig{Type: "Example", Index: map[int]string{3: "int"}})
+		assert.ErrorIs(t, err, ErrFieldNotFound)
+	})
+
+	t.Run("wildcard with except", func(t *testing.T) {
+		ed := parse(t)
+
+		applied, err := ed.ApplyConfig(config.TypeConfig{
+			Type:   "Example",
+			Fields: map[string]string{"*": "string", "Index": "[]string", "Total": "int32"},
+			Except: []string{"Total"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []FieldEdit{
+			{Struct: "Example", Field: "Index", OldType: "map[string]int", NewType: "[]string"},
+		}, applied)
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		ed := parse(t)
+		ed.SetStrict(true)
+
+		applied, err := ed.ApplyConfig(config.TypeConfig{
+			Type:   "Example",
+			Fields: map[string]string{"*At": "time.Time"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []FieldEdit{
+			{Struct: "Example", Field: "CreatedAt", OldType: "string", NewType: "time.Time"},
+		}, applied)
+		assert.Equal(t, map[string]string{"time": "time"}, ed.AddedImports())
+	})
+
+	t.Run("type uses in key order", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+var (
+	A int
+	B int
+	C int
+)
+
+type Example struct {
+	X map[string]int
+	Y map[string]int
+	Z map[string]int
+}
+`), 0644))
+		tc := config.TypeConfig{
+			Type:   "Example",
+			MapKey: map[string]string{"Z": "c.Key", "X": "a.Key", "Y": "b.Key"},
+			Vars:   map[string]string{"C": "int64", "A": "int8", "B": "int16"},
+		}
+		// Map iteration order changes between runs, so repeat to catch it.
+		for range 10 {
+			ed, err := ParseFile(filePath)
+			require.NoError(t, err)
+			_, err = ed.ApplyConfig(tc)
+			require.NoError(t, err)
+			assert.Equal(t, []TypeUse{
+				{Kind: "field", Subject: "Example.X", Type: "a.Key"},
+				{Kind: "field", Subject: "Example.Y", Type: "b.Key"},
+				{Kind: "field", Subject: "Example.Z", Type: "c.Key"},
+				{Kind: "var", Subject: "A", Type: "int8"},
+				{Kind: "var", Subject: "B", Type: "int16"},
+				{Kind: "var", Subject: "C", Type: "int64"},
+			}, ed.TypeUses())
+		}
+	})
+}
+
+func TestEditor_ApplyConfig_DropTag(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Total  *int64 `+"`json:\"total\"`"+` // in cents
+	Secret string `+"`json:\"secret\"`"+`
+	Name   string `+"`json:\"name\"`"+`
+	ID     int64
+	A, B   int `+"`json:\"-\"`"+`
+}
+`), 0644))
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	_, err = ed.ApplyConfig(config.TypeConfig{
+		Type:    "Example",
+		Fields:  map[string]string{"Total": "uint64"},
+		DropTag: []string{"Total", "Secret", "ID"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `package test
+
+type Example struct {
+	Total  uint64 // in cents
+	Secret string
+	Name   string `+"`json:\"name\"`"+`
+	ID     int64
+	A, B   int `+"`json:\"-\"`"+`
+}
+`, string(ed.Source()))
+
+	_, err = ed.ApplyConfig(config.TypeConfig{Type: "Example", DropTag: []string{"A"}})
+	assert.ErrorContains(t, err, "field Example.A is declared together with other fields")
+}
+
+func TestEditor_ApplyConfig_Where(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+type Row struct {
+	Name  string `+"`db:\"name,nullable\"`"+`
+	Email string `+"`db:\"email\"`"+`
+	Note  string `+"`json:\"note\" db:\",nullable\"`"+`
+	Count int
+}
+`), 0644))
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	applied, err := ed.ApplyConfig(config.TypeConfig{
+		Type:   "Row",
+		Fields: map[string]string{"*": "sql.NullString", "Count": "sql.NullInt64"},
+		Where:  config.FieldCondition{Tag: "db", Contains: "nullable"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []FieldEdit{
+		{Struct: "Row", Field: "Name", OldType: "string", NewType: "sql.NullString"},
+		{Struct: "Row", Field: "Note", OldType: "string", NewType: "sql.NullString"},
+	}, applied)
+}
+
+func TestEditor_Explain(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Base
+	*Meta
+	ID        int64
+	CreatedAt string
+	UpdatedAt string
+	Name      string
+}
+`), 0644))
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	decisions, err := ed.Explain(config.TypeConfig{
+		Type:   "Example",
+		Fields: map[string]string{"ID": "int64", "*At": "time.Time", "Meta": "Meta"},
+		Index:  map[int]string{3: "[]byte"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []FieldDecision{
+		{Struct: "Example", Field: "Base", OldType: "Base", Decision: "skipped-embedded"},
+		{Struct: "Example", Field: "Meta", Key: "Meta", OldType: "*Meta", NewType: "Meta", Decision: "changed"},
+		{Struct: "Example", Field: "ID", Key: "ID", OldType: "int64", NewType: "int64", Decision: "unchanged"},
+		{Struct: "Example", Field: "CreatedAt", Key: "*At", OldType: "string", NewType: "time.Time", Decision: "changed"},
+		{Struct: "Example", Field: "UpdatedAt", Key: "*At", OldType: "string", NewType: "time.Time", Decision: "changed"},
+		{Struct: "Example", Field: "Name", Key: "index 3", OldType: "string", NewType: "[]byte", Decision: "changed"},
+	}, decisions)
+	assert.False(t, ed.Modified())
+}
@@ -0,0 +1,279 @@
+package editor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const diffContext = 3
+
+// DiffOptions controls how Diff output is presented.
+type DiffOptions struct {
+	Context    int  // unchanged lines shown around each change
+	SideBySide bool // two columns instead of a unified diff
+	Width      int  // column width for side-by-side output
+}
+
+// DefaultDiffOptions renders a unified diff with three lines of context.
+var DefaultDiffOptions = DiffOptions{Context: diffContext, Width: 60}
+
+// Diff returns a unified diff between the source as parsed and the current
+// source. It is empty when nothing changed.
+func (e *Editor) Diff(path string) string {
+	return e.DiffWith(path, DefaultDiffOptions)
+}
+
+// DiffWith is like Diff but with custom presentation.
+func (e *Editor) DiffWith(path string, opts DiffOptions) string {
+	if string(e.orig) == string(e.src) {
+		return ""
+	}
+	ops := diffLines(splitLines(string(e.orig)), splitLines(string(e.src)))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", path, path)
+	for _, h := range hunkRanges(ops, opts.Context) {
+		sb.WriteString(hunkHeader(ops, h))
+		if opts.SideBySide {
+			sideBySide(&sb, ops[h.from:h.to], opts.Width)
+		} else {
+			unified(&sb, ops[h.from:h.to])
+		}
+	}
+	return sb.String()
+}
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a minimal line diff with Myers' linear-space algorithm,
+// so memory stays proportional to the input even for large generated files
+// with changes far apart.
+func diffLines(a, b []string) []diffOp {
+	ops := appendDiff(nil, a, b)
+	// Show the removed lines of each change before the added ones.
+	for start := 0; start < len(ops); {
+		end := start
+		for end < len(ops) && ops[end].kind != ' ' {
+			end++
+		}
+		slices.SortStableFunc(ops[start:end], func(x, y diffOp) int { return int(y.kind) - int(x.kind) })
+		start = end + 1
+	}
+	return ops
+}
+
+// appendDiff appends the diff of a and b to ops: the common prefix and
+// suffix are kept, and the rest is split at a middle snake and diffed
+// recursively.
+func appendDiff(ops []diffOp, a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(ma) == 0:
+		for _, line := range mb {
+			ops = append(ops, diffOp{'+', line})
+		}
+	case len(mb) == 0:
+		for _, line := range ma {
+			ops = append(ops, diffOp{'-', line})
+		}
+	default:
+		x, y, u, v := middleSnake(ma, mb)
+		ops = appendDiff(ops, ma[:x], mb[:y])
+		for _, line := range ma[x:u] {
+			ops = append(ops, diffOp{' ', line})
+		}
+		ops = appendDiff(ops, ma[u:], mb[v:])
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// middleSnake finds the middle snake of an optimal edit path from a to b,
+// which must both be non-empty: a[x:u] equals b[y:v], and the paths before and
+// after it take about half of the edits each.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+	// forward[k] is the furthest x reached on diagonal k = x-y from the start;
+	// backward[k] the same from the end, on the diagonals of the reversed
+	// inputs, where diagonal k meets forward diagonal delta-k.
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && forward[offset+k-1] < forward[offset+k+1] {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if c := delta - k; delta%2 != 0 && c >= -(d-1) && c <= d-1 && x+backward[offset+c] >= n {
+				return x0, y0, x, y
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && backward[offset+k-1] < backward[offset+k+1] {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if c := delta - k; delta%2 == 0 && c >= -d && c <= d && x+forward[offset+c] >= n {
+				return n - x, m - y, n - x0, m - y0
+			}
+		}
+	}
+	panic("diff: no middle snake")
+}
+
+type hunk struct {
+	from, to int
+}
+
+// hunkRanges groups diff operations into hunks with the given number of
+// context lines.
+func hunkRanges(ops []diffOp, context int) []hunk {
+	var result []hunk
+	for start := 0; start < len(ops); {
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		last := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				last = k
+			} else if k-last > 2*context {
+				break
+			}
+		}
+
+		h := hunk{from: max(first-context, start), to: min(last+context+1, len(ops))}
+		result = append(result, h)
+		start = h.to
+	}
+	return result
+}
+
+func hunkHeader(ops []diffOp, h hunk) string {
+	aLine, bLine := 1, 1
+	for _, op := range ops[:h.from] {
+		if op.kind != '+' {
+			aLine++
+		}
+		if op.kind != '-' {
+			bLine++
+		}
+	}
+	var aCount, bCount int
+	for _, op := range ops[h.from:h.to] {
+		if op.kind != '+' {
+			aCount++
+		}
+		if op.kind != '-' {
+			bCount++
+		}
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+}
+
+func unified(sb *strings.Builder, ops []diffOp) {
+	for _, op := range ops {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// sideBySide renders operations in two columns like sdiff: removed lines are
+// paired with the lines that replaced them and marked with |, unmatched ones
+// with < or >.
+func sideBySide(sb *strings.Builder, ops []diffOp, width int) {
+	row := func(left string, mark byte, right string) {
+		line := fmt.Sprintf("%-*s %c %s", width, column(left, width), mark, column(right, -1))
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			row(ops[i].line, ' ', ops[i].line)
+			i++
+			continue
+		}
+		var removed, added []string
+		for ; i < len(ops) && ops[i].kind == '-'; i++ {
+			removed = append(removed, ops[i].line)
+		}
+		for ; i < len(ops) && ops[i].kind == '+'; i++ {
+			added = append(added, ops[i].line)
+		}
+		for k := 0; k < max(len(removed), len(added)); k++ {
+			switch {
+			case k < len(removed) && k < len(added):
+				row(removed[k], '|', added[k])
+			case k < len(removed):
+				row(removed[k], '<', "")
+			default:
+				row("", '>', added[k])
+			}
+		}
+	}
+}
+
+// column prepares a line for a side-by-side column: tabs are expanded and the
+// text is cut to width runes unless width is negative.
+func column(line string, width int) string {
+	line = strings.ReplaceAll(strings.TrimSuffix(line, "\n"), "\t", "    ")
+	if runes := []rune(line); width >= 0 && len(runes) > width {
+		return string(runes[:width])
+	}
+	return line
+}
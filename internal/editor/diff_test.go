@@ -0,0 +1,131 @@
+package editor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditor_Diff(t *testing.T) {
+	t.Run("unified diff of edits", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	ID    int64
+	Total *int64
+	Name  string
+	A     int
+	B     int
+	C     int
+	D     int
+	E     int
+	F     int
+	G     int
+	H     int
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		assert.Empty(t, ed.Diff("types.go"))
+
+		_, err = ed.EditStruct("Example", map[string]string{"Total": "time.Time", "H": "uint"})
+		require.NoError(t, err)
+		ed.Apply()
+		require.NoError(t, ed.AddImports(map[string]string{"time": "time"}))
+
+		assert.Equal(t, `--- a/types.go
++++ b/types.go
+@@ -1,8 +1,12 @@
+ package test
+ 
++import (
++	"time"
++)
++
+ type Example struct {
+ 	ID    int64
+-	Total *int64
++	Total time.Time
+ 	Name  string
+ 	A     int
+ 	B     int
+@@ -11,5 +15,5 @@
+ 	E     int
+ 	F     int
+ 	G     int
+-	H     int
++	H     uint
+ }
+`, ed.Diff("types.go"))
+	})
+
+	t.Run("custom context and side by side", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	ID    int64
+	Total *int64
+}
+`), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		_, err = ed.EditStruct("Example", map[string]string{"Total": "uint64"})
+		require.NoError(t, err)
+		require.NoError(t, ed.Apply())
+
+		assert.Equal(t, `--- a/types.go
++++ b/types.go
+@@ -5,1 +5,1 @@
+-	Total *int64
++	Total uint64
+`, ed.DiffWith("types.go", DiffOptions{Context: 0}))
+
+		assert.Equal(t, `--- a/types.go
++++ b/types.go
+@@ -4,3 +4,3 @@
+    ID    int64         ID    int64
+    Total *int64  |     Total uint64
+}                   }
+`, ed.DiffWith("types.go", DiffOptions{Context: 1, SideBySide: true, Width: 17}))
+	})
+}
+
+func TestDiffLines(t *testing.T) {
+	ops := diffLines([]string{"a\n", "b\n", "c\n"}, []string{"a\n", "x\n", "c\n", "d\n"})
+	var kinds []byte
+	for _, op := range ops {
+		kinds = append(kinds, op.kind)
+	}
+	assert.Equal(t, " -+ +", string(kinds))
+}
+
+func TestDiffLines_LargeInput(t *testing.T) {
+	// Changes at both ends of a large file used to need a quadratic table.
+	a := make([]string, 20000)
+	for i := range a {
+		a[i] = fmt.Sprintf("\tField%d int\n", i)
+	}
+	b := slices.Concat([]string{"import \"time\"\n"}, a[:len(a)-1], []string{"\tField19999 time.Time\n"})
+
+	var kinds []byte
+	for _, op := range diffLines(a, b) {
+		if op.kind != ' ' {
+			kinds = append(kinds, op.kind)
+		}
+	}
+	assert.Equal(t, "+-+", string(kinds))
+}
@@ -1,55 +1,155 @@
 package editor
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"go/ast"
+	"go/build/constraint"
+	"go/format"
 	"go/parser"
+	"go/printer"
+	"go/scanner"
 	"go/token"
 	"os"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/reddec/editstruct/internal/config"
 )
 
 type Editor struct {
-	fset    *token.FileSet
-	file    *ast.File
-	src     []byte
-	imports *importManager
-	edits   []typeEdit
+	fset      *token.FileSet
+	file      *ast.File
+	src       []byte
+	orig      []byte
+	imports   *importManager
+	edits     []spanEdit
+	applied   []spanEdit
+	changes   int
+	crlf      bool
+	strict    bool
+	dups      DuplicateMode
+	noImports bool
+	hooks     []func(path string, src []byte) ([]byte, error)
+	typeUses  []TypeUse
 }
 
-type typeEdit struct {
-	start   int
-	end     int
-	newType string
+// DuplicateMode controls what happens when several type declarations in a
+// file share a name, e.g. behind different build tags.
+type DuplicateMode int
+
+const (
+	// DuplicatesAll edits every declaration with the name.
+	DuplicatesAll DuplicateMode = iota
+	// DuplicatesFirst edits only the first declaration in source order.
+	DuplicatesFirst
+	// DuplicatesError makes EditStruct fail with ErrDuplicateType.
+	DuplicatesError
+)
+
+type spanEdit struct {
+	start int
+	end   int
+	text  string
+	owner string // what requested the edit, e.g. "Example.Total"
 }
 
 type FieldEdit struct {
+	Struct  string
+	Field   string
 	OldType string
 	NewType string
 }
 
+// TypeUse is a type ApplyConfig wrote into the file and what it was written
+// for, e.g. a field, an interface method or a var.
+type TypeUse struct {
+	Kind    string // "field", "method" or "var"
+	Subject string // e.g. "Order.Total", "Store.Get" or "MaxSize"
+	Type    string
+}
+
+// TypeUses returns the types written by ApplyConfig so far, in order.
+func (e *Editor) TypeUses() []TypeUse {
+	return slices.Clone(e.typeUses)
+}
+
 func ParseFile(path string) (*Editor, error) {
 	src, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read file: %w", err)
 	}
+	return ParseSource(path, src)
+}
 
+// ParseSource parses Go source that has already been read. The path is used
+// in positions and error messages only.
+func ParseSource(path string, src []byte) (*Editor, error) {
 	fset := token.NewFileSet()
 	file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
 	if err != nil {
-		return nil, fmt.Errorf("parse file: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrParse, err)
 	}
 
 	return &Editor{
 		fset:    fset,
 		file:    file,
 		src:     src,
+		orig:    bytes.Clone(src),
 		imports: newImportManager(file, fset, src),
 		edits:   nil,
+		crlf:    isCRLF(src),
 	}, nil
 }
 
+// FileSet returns the file set the current Source() was parsed with. The
+// source is parsed again into a new file set after every Apply, AddImports and
+// Format that changes it, so a file set obtained before one of them is stale.
+func (e *Editor) FileSet() *token.FileSet {
+	return e.fset
+}
+
+// AST returns the parsed file. It is shared with the editor and must be treated
+// as read-only: mutating it directly is unsupported.
+func (e *Editor) AST() *ast.File {
+	return e.file
+}
+
+// BuildConstraint returns the file's //go:build (or legacy // +build)
+// constraint, or nil when the file is unconstrained.
+func (e *Editor) BuildConstraint() (constraint.Expr, error) {
+	var plusBuild []constraint.Expr
+	for _, group := range e.file.Comments {
+		if group.Pos() >= e.file.Package {
+			break
+		}
+		for _, c := range group.List {
+			switch {
+			case constraint.IsGoBuild(c.Text):
+				return constraint.Parse(c.Text)
+			case constraint.IsPlusBuild(c.Text):
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					return nil, err
+				}
+				plusBuild = append(plusBuild, expr)
+			}
+		}
+	}
+
+	if len(plusBuild) == 0 {
+		return nil, nil
+	}
+	expr := plusBuild[0]
+	for _, next := range plusBuild[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: next}
+	}
+	return expr, nil
+}
+
 func (e *Editor) StructNames() []string {
 	var names []string
 	for _, decl := range e.file.Decls {
@@ -68,9 +168,77 @@ func (e *Editor) StructNames() []string {
 	return names
 }
 
+// ImportPaths returns the paths of all packages imported by the file.
+func (e *Editor) ImportPaths() []string {
+	paths := make([]string, 0, len(e.file.Imports))
+	for _, spec := range e.file.Imports {
+		if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
 func (e *Editor) EditStruct(structName string, fieldEdits map[string]string) (bool, error) {
-	var modified bool
+	applied, err := e.EditStructDetailed(structName, fieldEdits)
+	return len(applied) > 0, err
+}
+
+// SetStrict makes EditStruct fail with ErrStructNotFound or ErrFieldNotFound
+// instead of silently ignoring missing structs and fields.
+func (e *Editor) SetStrict(strict bool) {
+	e.strict = strict
+}
+
+// SetDuplicates selects how types declared more than once are edited.
+func (e *Editor) SetDuplicates(mode DuplicateMode) {
+	e.dups = mode
+}
+
+// EditStructDetailed is like EditStruct but reports every field edit it queued.
+func (e *Editor) EditStructDetailed(structName string, fieldEdits map[string]string) ([]FieldEdit, error) {
+	if e.dups == DuplicatesError {
+		if n := len(e.typeDecls(structName)); n > 1 {
+			return nil, fmt.Errorf("%w: %s is declared %d times", ErrDuplicateType, structName, n)
+		}
+	}
+	structs := e.structTypes(structName)
+	if e.strict {
+		if len(structs) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrStructNotFound, structName)
+		}
+		for _, fieldName := range sortedKeys(fieldEdits) {
+			for _, st := range structs {
+				if lookupField(st, fieldName) == nil {
+					return nil, fmt.Errorf("%w: %s.%s", ErrFieldNotFound, structName, fieldName)
+				}
+			}
+		}
+	}
+
+	var applied []FieldEdit
+	for _, st := range structs {
+		applied = append(applied, e.collectFieldEdits(structName, st, fieldEdits)...)
+	}
+	return applied, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
+type typeDecl struct {
+	gen  *ast.GenDecl
+	spec *ast.TypeSpec
+}
+
+func (e *Editor) typeDecls(typeName string) []typeDecl {
+	var found []typeDecl
 	for _, decl := range e.file.Decls {
 		gd, ok := decl.(*ast.GenDecl)
 		if !ok || gd.Tok != token.TYPE {
@@ -79,68 +247,301 @@ func (e *Editor) EditStruct(structName string, fieldEdits map[string]string) (bo
 
 		for _, spec := range gd.Specs {
 			ts, ok := spec.(*ast.TypeSpec)
-			if !ok || ts.Name.Name != structName {
+			if !ok || ts.Name.Name != typeName {
 				continue
 			}
-
-			st, ok := ts.Type.(*ast.StructType)
-			if !ok {
-				continue
+			found = append(found, typeDecl{gen: gd, spec: ts})
+			if e.dups == DuplicatesFirst {
+				return found
 			}
+		}
+	}
+	return found
+}
 
-			changed := e.collectFieldEdits(st, fieldEdits)
-			if changed {
-				modified = true
-			}
+func (e *Editor) structTypes(structName string) []*ast.StructType {
+	var found []*ast.StructType
+	for _, td := range e.typeDecls(structName) {
+		if st, ok := td.spec.Type.(*ast.StructType); ok {
+			found = append(found, st)
 		}
 	}
+	return found
+}
+
+func (e *Editor) offset(pos token.Pos) int {
+	return e.fset.Position(pos).Offset
+}
 
-	return modified, nil
+// fieldPlan describes how one field declaration changes: the names whose type
+// changes and the names of the same declaration that keep it.
+type fieldPlan struct {
+	field   *ast.Field
+	changed []FieldEdit
+	kept    []string
 }
 
-func (e *Editor) collectFieldEdits(st *ast.StructType, fieldEdits map[string]string) bool {
-	var modified bool
+// planFieldEdits works out which fields of one struct change without queueing
+// anything. Keys with a dot, such as "Address.Zip", address fields of inline
+// struct types.
+func (e *Editor) planFieldEdits(structName string, st *ast.StructType, fieldEdits map[string]string) []fieldPlan {
+	var plans []fieldPlan
+
+	nested := make(map[string]map[string]string)
+	for key, newType := range fieldEdits {
+		if outer, inner, ok := strings.Cut(key, "."); ok {
+			if nested[outer] == nil {
+				nested[outer] = make(map[string]string)
+			}
+			nested[outer][inner] = newType
+		}
+	}
 
 	for _, field := range st.Fields.List {
+		oldType := e.typeString(field.Type)
 		if len(field.Names) == 0 {
+			name := embeddedName(field.Type)
+			if newType, ok := fieldEdits[name]; ok && normalizeType(newType) != oldType {
+				fe := FieldEdit{Struct: structName, Field: name, OldType: oldType, NewType: normalizeType(newType)}
+				plans = append(plans, fieldPlan{field: field, changed: []FieldEdit{fe}})
+			}
 			continue
 		}
 
+		if inline := inlineStruct(field.Type); inline != nil {
+			for _, name := range field.Names {
+				if edits := nested[name.Name]; edits != nil {
+					plans = append(plans, e.planFieldEdits(structName+"."+name.Name, inline, edits)...)
+				}
+			}
+		}
+
+		plan := fieldPlan{field: field}
 		for _, name := range field.Names {
 			newType, ok := fieldEdits[name.Name]
-			if !ok {
+			if !ok || normalizeType(newType) == oldType {
+				plan.kept = append(plan.kept, name.Name)
 				continue
 			}
+			plan.changed = append(plan.changed, FieldEdit{
+				Struct:  structName,
+				Field:   name.Name,
+				OldType: oldType,
+				NewType: normalizeType(newType),
+			})
+		}
+		if len(plan.changed) > 0 {
+			plans = append(plans, plan)
+		}
+	}
 
-			oldType := e.typeString(field.Type)
-			if oldType == newType {
-				continue
-			}
+	return plans
+}
+
+// collectFieldEdits queues the edits of one struct.
+func (e *Editor) collectFieldEdits(structName string, st *ast.StructType, fieldEdits map[string]string) []FieldEdit {
+	var applied []FieldEdit
+	for _, plan := range e.planFieldEdits(structName, st, fieldEdits) {
+		applied = append(applied, plan.changed...)
+		if len(plan.kept) == 0 && sameNewType(plan.changed) {
+			e.replaceFieldType(plan.field, plan.changed[0])
+			continue
+		}
+		e.splitFieldGroup(plan.field, plan.kept, plan.changed)
+	}
+	return applied
+}
+
+// inlineStruct returns the struct type of a field declared as an inline struct,
+// including pointers, slices and arrays of one.
+func inlineStruct(expr ast.Expr) *ast.StructType {
+	for {
+		switch t := expr.(type) {
+		case *ast.StructType:
+			return t
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.ArrayType:
+			expr = t.Elt
+		default:
+			return nil
+		}
+	}
+}
 
-			start := e.fset.Position(field.Type.Pos()).Offset
-			end := e.fset.Position(field.Type.End()).Offset
-			e.edits = append(e.edits, typeEdit{start: start, end: end, newType: newType})
-			modified = true
+// lookupField finds a field by name or by a dotted path into inline structs.
+func lookupField(st *ast.StructType, path string) *ast.Field {
+	outer, inner, nested := strings.Cut(path, ".")
+	field := findField(st, outer)
+	if !nested || field == nil {
+		return field
+	}
+	if inline := inlineStruct(field.Type); inline != nil {
+		return lookupField(inline, inner)
+	}
+	return nil
+}
+
+func sameNewType(edits []FieldEdit) bool {
+	for _, fe := range edits[1:] {
+		if fe.NewType != edits[0].NewType {
+			return false
 		}
 	}
+	return true
+}
+
+func (e *Editor) replaceFieldType(field *ast.Field, fe FieldEdit) {
+	start := e.offset(field.Type.Pos())
+	end := e.offset(field.Type.End())
+	e.edits = append(e.edits, spanEdit{start: start, end: end, text: fe.NewType, owner: fe.Struct + "." + fe.Field})
+}
+
+// splitFieldGroup edits some names of a grouped field such as `A, B, C int`.
+// Names that keep their type stay on the original line; edited names move to
+// lines of their own after it, carrying the tag with them. If every name is
+// edited, the first one stays in place with its new type.
+func (e *Editor) splitFieldGroup(field *ast.Field, kept []string, changed []FieldEdit) {
+	if len(kept) == 0 {
+		e.replaceFieldType(field, changed[0])
+		kept, changed = []string{changed[0].Field}, changed[1:]
+	}
+
+	owner := changed[0].Struct + "." + changed[0].Field
+	namesStart := e.offset(field.Names[0].Pos())
+	namesEnd := e.offset(field.Names[len(field.Names)-1].End())
+	e.edits = append(e.edits, spanEdit{start: namesStart, end: namesEnd, text: strings.Join(kept, ", "), owner: owner})
 
-	return modified
+	var tag string
+	if field.Tag != nil {
+		tag = " " + field.Tag.Value
+	}
+	lineEnd := e.offset(field.End())
+	if field.Comment != nil {
+		lineEnd = e.offset(field.Comment.End())
+	}
+	indent := e.lineIndent(field.Pos())
+	var sb strings.Builder
+	for _, fe := range changed {
+		sb.WriteString("\n" + indent + fe.Field + " " + fe.NewType + tag)
+	}
+	e.edits = append(e.edits, spanEdit{start: lineEnd, end: lineEnd, text: sb.String(), owner: owner})
 }
 
-func (e *Editor) Apply() {
+// Apply splices all queued edits into the source and re-parses it, so further
+// edits can be queued against the updated code. Overlapping edits are rejected.
+func (e *Editor) Apply() error {
 	if len(e.edits) == 0 {
-		return
+		return nil
 	}
 
-	sort.Slice(e.edits, func(i, j int) bool {
-		return e.edits[i].start > e.edits[j].start
+	// Splice from the end of the source so earlier offsets stay valid. Inserts
+	// sharing an offset are spliced last-queued first, so they end up in the
+	// order they were queued, in front of a replacement starting there.
+	queued := e.edits
+	e.edits = nil
+	order := make([]int, len(queued))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(i, j int) int {
+		a, b := queued[i], queued[j]
+		if a.start != b.start {
+			return b.start - a.start
+		}
+		if a.end != b.end {
+			return b.end - a.end
+		}
+		return j - i
 	})
+	edits := make([]spanEdit, len(order))
+	for i, k := range order {
+		edits[i] = queued[k]
+	}
+	for i := 1; i < len(edits); i++ {
+		if edits[i].end > edits[i-1].start {
+			return fmt.Errorf("conflicting edits of %s and %s", edits[i].owner, edits[i-1].owner)
+		}
+	}
 
-	for _, edit := range e.edits {
-		e.src = append(e.src[:edit.start], append([]byte(edit.newType), e.src[edit.end:]...)...)
+	batch := len(e.applied)
+	e.changes += len(edits)
+	for _, edit := range edits {
+		e.splice(edit.start, edit.end, edit.text)
+		e.applied = append(e.applied, spanEdit{start: edit.start, end: edit.start + len(edit.text), owner: edit.owner})
 	}
 
-	e.edits = nil
+	if err := e.reparse(); err != nil {
+		return err
+	}
+	return e.retabulate(e.applied[batch:])
+}
+
+// retabulate reformats the declarations touched by spans, so that a type
+// of a different width does not leave tags and comments misaligned.
+func (e *Editor) retabulate(spans []spanEdit) error {
+	var decls []*ast.GenDecl
+	for _, decl := range e.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok == token.IMPORT {
+			continue
+		}
+		start, end := e.offset(gen.Pos()), e.offset(gen.End())
+		for _, span := range spans {
+			if span.start >= start && span.start < end {
+				decls = append(decls, gen)
+				break
+			}
+		}
+	}
+
+	const header = "package p\n\n"
+	changed := false
+	for i := len(decls) - 1; i >= 0; i-- {
+		start, end := e.offset(decls[i].Pos()), e.offset(decls[i].End())
+		formatted, err := format.Source(append([]byte(header), e.src[start:end]...))
+		if err != nil {
+			continue
+		}
+		text := strings.TrimSuffix(strings.TrimPrefix(string(formatted), header), "\n")
+		if text != string(e.src[start:end]) {
+			e.splice(start, end, text)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return e.reparse()
+}
+
+// reparse refreshes the AST after the source was changed.
+func (e *Editor) reparse() error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, e.filename(), e.src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return e.sourceError(err)
+	}
+	e.fset, e.file = fset, file
+	e.imports.fset, e.imports.file = fset, file
+	return nil
+}
+
+func (e *Editor) filename() string {
+	return e.fset.File(e.file.Pos()).Name()
+}
+
+// splice replaces src[start:end] with text, keeping the positions of already
+// applied edits in sync.
+func (e *Editor) splice(start, end int, text string) {
+	e.src = append(e.src[:start], append([]byte(text), e.src[end:]...)...)
+	delta := len(text) - (end - start)
+	for i := range e.applied {
+		if e.applied[i].start >= end {
+			e.applied[i].start += delta
+			e.applied[i].end += delta
+		}
+	}
 }
 
 func (e *Editor) typeString(expr ast.Expr) string {
@@ -152,24 +553,170 @@ func (e *Editor) typeString(expr ast.Expr) string {
 	case *ast.StarExpr:
 		return "*" + e.typeString(t.X)
 	case *ast.ArrayType:
+		if t.Len != nil {
+			return "[" + e.exprString(t.Len) + "]" + e.typeString(t.Elt)
+		}
 		return "[]" + e.typeString(t.Elt)
 	case *ast.MapType:
 		return fmt.Sprintf("map[%s]%s", e.typeString(t.Key), e.typeString(t.Value))
+	case *ast.ChanType:
+		return chanPrefix(t.Dir) + e.typeString(t.Value)
 	default:
+		return e.exprString(expr)
+	}
+}
+
+func (e *Editor) exprString(expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, e.fset, expr); err != nil {
 		return ""
 	}
+	return buf.String()
+}
+
+// normalizeType renders a type expression the way gofmt would, so that
+// spelling differences such as "map[string] int" compare equal. Strings that
+// do not parse are only trimmed.
+func normalizeType(typeStr string) string {
+	typeStr = strings.TrimSpace(typeStr)
+	expr, err := parser.ParseExpr(typeStr)
+	if err != nil {
+		return typeStr
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, token.NewFileSet(), expr); err != nil {
+		return typeStr
+	}
+	return buf.String()
+}
+
+// VerifySyntax re-parses the current source and reports syntax errors
+// introduced by edits, naming the edit closest before the error. It does not
+// run go/types, so type errors and unresolved identifiers are not detected.
+func (e *Editor) VerifySyntax() error {
+	_, err := parser.ParseFile(token.NewFileSet(), e.filename(), e.src, parser.SkipObjectResolution)
+	if err == nil {
+		return nil
+	}
+	return e.sourceError(err)
+}
+
+func (e *Editor) sourceError(err error) error {
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		if owner := e.ownerAt(list[0].Pos.Offset); owner != "" {
+			return fmt.Errorf("edit of %s produced invalid source: %w: %w", owner, ErrParse, err)
+		}
+	}
+	return fmt.Errorf("invalid source: %w: %w", ErrParse, err)
+}
+
+func (e *Editor) ownerAt(offset int) string {
+	var owner string
+	best := -1
+	for _, span := range e.applied {
+		if span.start <= offset && span.start > best {
+			best = span.start
+			owner = span.owner
+		}
+	}
+	return owner
 }
 
 func (e *Editor) AddImports(required map[string]string) error {
-	return e.imports.add(required, &e.src)
+	if err := e.imports.add(required, e.splice); err != nil {
+		return err
+	}
+	return e.reparse()
+}
+
+// AddedImports returns the imports inserted by AddImports, keyed by alias.
+func (e *Editor) AddedImports() map[string]string {
+	added := make(map[string]string, len(e.imports.added))
+	for _, spec := range e.imports.added {
+		added[spec.alias] = spec.path
+	}
+	return added
+}
+
+// Format runs gofmt over the whole current source. Applied edits are not
+// tracked across formatting, so it should be the last step before writing.
+func (e *Editor) Format() error {
+	formatted, err := format.Source(e.src)
+	if err != nil {
+		return e.sourceError(err)
+	}
+	if bytes.Equal(formatted, e.src) {
+		return nil
+	}
+	e.src = formatted
+	e.applied = nil
+	return e.reparse()
+}
+
+// Formatted reports whether the current source is already gofmt-clean.
+func (e *Editor) Formatted() (bool, error) {
+	formatted, err := format.Source(e.src)
+	if err != nil {
+		return false, e.sourceError(err)
+	}
+	return bytes.Equal(formatted, e.src), nil
+}
+
+// Changes returns how many edits Apply has made to the source: each changed
+// field type, tag or comment, added or removed field and so on counts once.
+// Added imports and reformatting are not counted.
+func (e *Editor) Changes() int {
+	return e.changes
+}
+
+// Modified reports whether the source differs from the file as parsed.
+func (e *Editor) Modified() bool {
+	return !bytes.Equal(e.orig, e.src)
 }
 
+// Source returns a copy of the current source; mutating it does not affect the editor.
 func (e *Editor) Source() []byte {
-	return e.src
+	return bytes.Clone(e.src)
+}
+
+// Output returns the exact bytes WriteTo writes: the current source with the
+// file's original line endings restored.
+func (e *Editor) Output() []byte {
+	if e.crlf {
+		return toCRLF(e.src)
+	}
+	return bytes.Clone(e.src)
+}
+
+// OnBeforeWrite registers a hook that WriteTo runs on the final bytes before
+// writing them, e.g. to run another formatter or add a header. Hooks run in
+// registration order, each receiving the previous one's result; an error
+// aborts the write.
+func (e *Editor) OnBeforeWrite(hook func(path string, src []byte) ([]byte, error)) {
+	e.hooks = append(e.hooks, hook)
 }
 
 func (e *Editor) WriteTo(path string) error {
-	return os.WriteFile(path, e.src, 0644)
+	src := e.Output()
+	for _, hook := range e.hooks {
+		var err error
+		if src, err = hook(path, src); err != nil {
+			return fmt.Errorf("before write hook: %w", err)
+		}
+	}
+	return os.WriteFile(path, src, 0644)
+}
+
+// isCRLF reports whether CRLF is the dominant line ending of src.
+func isCRLF(src []byte) bool {
+	crlf := bytes.Count(src, []byte("\r\n"))
+	return crlf > 0 && crlf >= bytes.Count(src, []byte("\n"))-crlf
+}
+
+func toCRLF(src []byte) []byte {
+	src = bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(src, []byte("\n"), []byte("\r\n"))
 }
 
 func ParseTypeString(typeStr string) (pkgPath string, typeName string, isPointer bool) {
@@ -186,12 +733,14 @@ func ParseTypeString(typeStr string) (pkgPath string, typeName string, isPointer
 	return "", typeStr, isPointer
 }
 
+// RequiredImports returns the package selectors used by the given types,
+// including those nested in composite and generic types such as
+// Map[string, time.Time].
 func (e *Editor) RequiredImports(fieldEdits map[string]string) map[string]string {
 	imports := make(map[string]string)
 	for _, typeStr := range fieldEdits {
-		pkgPath, _, _ := ParseTypeString(typeStr)
-		if pkgPath != "" {
-			imports[pkgPath] = pkgPath
+		for _, alias := range config.PackageSelectors(typeStr) {
+			imports[alias] = alias
 		}
 	}
 	return imports
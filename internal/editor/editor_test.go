@@ -1,8 +1,12 @@
 package editor
 
 import (
+	"bytes"
+	"errors"
+	"go/ast"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -43,6 +47,7 @@ type Example struct {`), 0644)
 		_, err = ParseFile(filePath)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "parse file")
+		assert.ErrorIs(t, err, ErrParse)
 	})
 }
 
@@ -337,14 +342,58 @@ type Example struct {
 		require.NoError(t, err)
 		assert.True(t, modified)
 
-		ed.Apply()
+		require.NoError(t, ed.Apply())
 
-		src := string(ed.Source())
-		assert.Contains(t, src, "ID")
-		assert.Contains(t, src, "string")
-		assert.Contains(t, src, "Count")
-		assert.Contains(t, src, "int64")
-		assert.Contains(t, src, "Name")
+		assert.Equal(t, `package test
+
+type Example struct {
+	ID    string
+	Name  string
+	Count int64
+}
+`, string(ed.Source()))
+	})
+
+	t.Run("every field with types of other widths", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		original := `package test
+
+type Example struct {
+	A int `+"`json:\"a\"`"+` // first
+	B *string
+	C map[string]int
+	D []byte `+"`json:\"d\"`"+`
+	E int
+}
+`
+		require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		// Longer and shorter replacements, so stale offsets would corrupt
+		// the fields after the first one.
+		modified, err := ed.EditStruct("Example", map[string]string{
+			"A": "map[string][]*time.Time",
+			"B": "x",
+			"C": "int",
+			"D": "[]map[uuid.UUID]string",
+			"E": "uint8",
+		})
+		require.NoError(t, err)
+		assert.True(t, modified)
+		require.NoError(t, ed.Apply())
+
+		assert.Equal(t, `package test
+
+type Example struct {
+	A map[string][]*time.Time `+"`json:\"a\"`"+` // first
+	B x
+	C int
+	D []map[uuid.UUID]string `+"`json:\"d\"`"+`
+	E uint8
+}
+`, string(ed.Source()))
 	})
 
 	t.Run("skips embedded fields", func(t *testing.T) {
@@ -502,6 +551,105 @@ type Example struct {
 		assert.Contains(t, src, `"fmt"`)
 	})
 
+	t.Run("added imports are sorted", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\nimport \"fmt\"\n"), 0644))
+
+		// Repeat, as map iteration order varies between runs.
+		for range 20 {
+			ed, err := ParseFile(filePath)
+			require.NoError(t, err)
+			require.NoError(t, ed.AddImports(map[string]string{
+				"uuid":    "github.com/google/uuid",
+				"time":    "time",
+				"decimal": "github.com/shopspring/decimal",
+			}))
+			assert.Equal(t, "package test\n\nimport (\n\t\"fmt\"\n\t\"time\"\n\n\t\"github.com/google/uuid\"\n\t\"github.com/shopspring/decimal\"\n)\n", string(ed.Source()))
+		}
+	})
+
+	t.Run("grouped block", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+import (
+	"fmt"
+	"strings" // for Builder
+
+	// y does things
+	"github.com/x/y"
+)
+
+type Example struct {
+	ID  string
+	Buf string
+}
+`), 0644))
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		_, err = ed.EditStruct("Example", map[string]string{"ID": "uuid.UUID", "Buf": "bytes.Buffer"})
+		require.NoError(t, err)
+		require.NoError(t, ed.Apply())
+		require.NoError(t, ed.AddImports(map[string]string{"uuid": "github.com/google/uuid", "bytes": "bytes", "z": "github.com/x/z"}))
+
+		assert.Equal(t, `package test
+
+import (
+	"bytes"
+	"fmt"
+	"strings" // for Builder
+
+	"github.com/google/uuid"
+	// y does things
+	"github.com/x/y"
+	"github.com/x/z"
+)
+
+type Example struct {
+	ID  uuid.UUID
+	Buf bytes.Buffer
+}
+`, string(ed.Source()))
+		formatted, err := ed.Formatted()
+		require.NoError(t, err)
+		assert.True(t, formatted)
+	})
+
+	t.Run("new group", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\nimport (\n\t\"github.com/x/y\"\n)\n"), 0644))
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		require.NoError(t, ed.AddImports(map[string]string{"time": "time"}))
+		assert.Equal(t, "package test\n\nimport (\n\t\"time\"\n\n\t\"github.com/x/y\"\n)\n", string(ed.Source()))
+
+		filePath = filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\nimport (\n\t\"time\"\n)\n"), 0644))
+		ed, err = ParseFile(filePath)
+		require.NoError(t, err)
+		require.NoError(t, ed.AddImports(map[string]string{"y": "github.com/x/y"}))
+		assert.Equal(t, "package test\n\nimport (\n\t\"time\"\n\n\t\"github.com/x/y\"\n)\n", string(ed.Source()))
+	})
+
+	t.Run("new group next to added import", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n"), 0644))
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		require.NoError(t, ed.AddImports(map[string]string{"strings": "strings", "x": "github.com/x/x"}))
+		assert.Equal(t, "package test\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\t\"strings\"\n\n\t\"github.com/x/x\"\n)\n", string(ed.Source()))
+
+		filePath = filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\nimport (\n\t\"github.com/x/y\"\n)\n"), 0644))
+		ed, err = ParseFile(filePath)
+		require.NoError(t, err)
+		require.NoError(t, ed.AddImports(map[string]string{"time": "time", "a": "github.com/a/a"}))
+		assert.Equal(t, "package test\n\nimport (\n\t\"time\"\n\n\t\"github.com/a/a\"\n\t\"github.com/x/y\"\n)\n", string(ed.Source()))
+	})
+
 	t.Run("empty required imports", func(t *testing.T) {
 		dir := t.TempDir()
 		filePath := filepath.Join(dir, "types.go")
@@ -556,6 +704,58 @@ type Example struct {
 	})
 }
 
+func TestEditor_WriteTo_CRLF(t *testing.T) {
+	t.Run("preserve CRLF line endings", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := "package test\r\n\r\ntype Example struct {\r\n\tTotal *int64\r\n}\r\n"
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.EditStruct("Example", map[string]string{"Total": "time.Time"})
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+		require.NoError(t, ed.AddImports(map[string]string{"time": "time"}))
+
+		err = ed.WriteTo(filePath)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "\tTotal time.Time\r\n")
+		assert.Contains(t, string(content), "\t\"time\"\r\n")
+		assert.NotRegexp(t, "[^\r]\n", string(content))
+		assert.Equal(t, content, ed.Output())
+	})
+
+	t.Run("keep LF line endings", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := "package test\n\ntype Example struct {\n\tTotal *int64\n}\n"
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		_, err = ed.EditStruct("Example", map[string]string{"Total": "uint64"})
+		require.NoError(t, err)
+		ed.Apply()
+
+		err = ed.WriteTo(filePath)
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.NotContains(t, string(content), "\r")
+	})
+}
+
 func TestEditor_Source(t *testing.T) {
 	dir := t.TempDir()
 	filePath := filepath.Join(dir, "types.go")
@@ -573,6 +773,16 @@ type Example struct {
 
 	src := ed.Source()
 	assert.Equal(t, original, string(src))
+
+	copy(src, "XXXXXXX")
+	assert.Equal(t, original, string(ed.Source()))
+
+	modified, err := ed.EditStruct("Example", map[string]string{"ID": "uint64"})
+	require.NoError(t, err)
+	assert.True(t, modified)
+	ed.Apply()
+	assert.Contains(t, string(ed.Source()), "package test")
+	assert.Contains(t, string(ed.Source()), "ID uint64")
 }
 
 func TestParseTypeString(t *testing.T) {
@@ -647,6 +857,38 @@ type Example struct{ ID int64 }
 		assert.Contains(t, imports, "uuid")
 		assert.Contains(t, imports, "time")
 	})
+
+	t.Run("generic type arguments", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Cache map[string]string
+}
+`), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		fields := map[string]string{"Cache": "Map[string, time.Time]"}
+		imports := ed.RequiredImports(fields)
+		assert.Equal(t, map[string]string{"time": "time"}, imports)
+		assert.Equal(t, map[string]string{"cache": "cache", "uuid": "uuid", "time": "time"}, ed.RequiredImports(map[string]string{
+			"Cache": "cache.Map[uuid.UUID, *time.Time]",
+		}))
+
+		_, err = ed.EditStruct("Example", fields)
+		require.NoError(t, err)
+		require.NoError(t, ed.Apply())
+		require.NoError(t, ed.AddImports(imports))
+
+		src := string(ed.Source())
+		assert.Contains(t, src, "Cache Map[string, time.Time]")
+		assert.Contains(t, src, "import (\n\t\"time\"\n)")
+		assert.Equal(t, "Map[string, time.Time]", ed.typeString(ed.structTypes("Example")[0].Fields.List[0].Type))
+	})
 }
 
 func TestEditor_TypeString(t *testing.T) {
@@ -802,6 +1044,110 @@ type Example struct {
 	})
 }
 
+func TestEditor_AddImports_Indent(t *testing.T) {
+	write := func(t *testing.T, src string) *Editor {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte(src), 0644))
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		return ed
+	}
+
+	t.Run("detect spaces", func(t *testing.T) {
+		ed := write(t, `package test
+
+import "fmt"
+
+type Example struct {
+    ID fmt.Stringer
+}
+`)
+		indent := ed.DetectIndent()
+		assert.Equal(t, "    ", indent)
+
+		ed.SetIndent(indent)
+		require.NoError(t, ed.AddImports(map[string]string{"time": "time"}))
+		assert.Equal(t, `package test
+
+import (
+    "fmt"
+    "time"
+)
+
+type Example struct {
+    ID fmt.Stringer
+}
+`, string(ed.Source()))
+	})
+
+	t.Run("new block", func(t *testing.T) {
+		ed := write(t, `package test
+
+type Example struct {
+  ID int64
+}
+`)
+		ed.SetIndent(ed.DetectIndent())
+		require.NoError(t, ed.AddImports(map[string]string{"time": "time"}))
+		assert.Contains(t, string(ed.Source()), "import (\n  \"time\"\n)\n")
+	})
+
+	t.Run("default tab", func(t *testing.T) {
+		ed := write(t, "package test\n\nconst X = 1\n")
+		assert.Equal(t, "\t", ed.DetectIndent())
+	})
+}
+
+func TestEditor_AddImports_DotImport(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+import (
+	. "time"
+	_ "embed"
+)
+
+type Example struct {
+	Timeout   int64
+	CreatedAt string
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	fieldEdits := map[string]string{"Timeout": "Duration"}
+	_, err = ed.EditStruct("Example", fieldEdits)
+	require.NoError(t, err)
+	require.NoError(t, ed.Apply())
+	assert.Empty(t, ed.RequiredImports(fieldEdits))
+	require.NoError(t, ed.AddImports(ed.RequiredImports(fieldEdits)))
+	assert.Empty(t, ed.AddedImports())
+
+	fieldEdits = map[string]string{"CreatedAt": "time.Time"}
+	_, err = ed.EditStruct("Example", fieldEdits)
+	require.NoError(t, err)
+	require.NoError(t, ed.Apply())
+	require.NoError(t, ed.AddImports(ed.RequiredImports(fieldEdits)))
+	assert.Equal(t, map[string]string{"time": "time"}, ed.AddedImports())
+
+	assert.Equal(t, `package test
+
+import (
+	. "time"
+	_ "embed"
+	"time"
+)
+
+type Example struct {
+	Timeout   Duration
+	CreatedAt time.Time
+}
+`, string(ed.Source()))
+	assert.NoError(t, ed.VerifyImports())
+}
+
 func TestEditor_AddImports_WithAlias(t *testing.T) {
 	t.Run("import with alias already exists", func(t *testing.T) {
 		dir := t.TempDir()
@@ -831,6 +1177,28 @@ type Example struct {
 	})
 }
 
+func TestEditor_AddImports_AliasDiffersFromPath(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	ID int64
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	err = ed.AddImports(map[string]string{"db": "example.com/storage", "uuid": "github.com/google/uuid"})
+	require.NoError(t, err)
+
+	src := string(ed.Source())
+	assert.Contains(t, src, "\tdb \"example.com/storage\"\n")
+	assert.Contains(t, src, "\t\"github.com/google/uuid\"\n")
+}
+
 func TestEditor_InsertImportBeforeType(t *testing.T) {
 	t.Run("import inserted before first type declaration", func(t *testing.T) {
 		dir := t.TempDir()
@@ -969,6 +1337,55 @@ import "fmt"
 	})
 }
 
+func TestEditor_AddImports_FileTail(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		want     string
+	}{
+		{
+			name:     "package clause only",
+			original: "package test\n",
+			want:     "package test\n\nimport (\n\t\"time\"\n)\n",
+		},
+		{
+			name:     "trailing comment",
+			original: "package test\n\n// tail\n",
+			want:     "package test\n\nimport (\n\t\"time\"\n)\n\n// tail\n",
+		},
+		{
+			name:     "doc comment of first declaration",
+			original: "package test // import \"example.com/test\"\n\n// Example doc\ntype Example struct {\n\tID int64\n}\n",
+			want:     "package test // import \"example.com/test\"\n\nimport (\n\t\"time\"\n)\n\n// Example doc\ntype Example struct {\n\tID time.Time\n}\n",
+		},
+		{
+			name:     "no final newline",
+			original: "package test\n\ntype Example struct {\n\tID int64\n}",
+			want:     "package test\n\nimport (\n\t\"time\"\n)\n\ntype Example struct {\n\tID time.Time\n}",
+		},
+		{
+			name:     "trailing blank line",
+			original: "package test\n\nimport \"fmt\"\n\ntype Example struct {\n\tID fmt.Stringer\n}\n\n",
+			want:     "package test\n\nimport (\n\t\"fmt\"\n\t\"time\"\n)\n\ntype Example struct {\n\tID time.Time\n}\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "types.go")
+			require.NoError(t, os.WriteFile(filePath, []byte(tt.original), 0644))
+
+			ed, err := ParseFile(filePath)
+			require.NoError(t, err)
+
+			_, err = ed.EditStruct("Example", map[string]string{"ID": "time.Time"})
+			require.NoError(t, err)
+			require.NoError(t, ed.Apply())
+			require.NoError(t, ed.AddImports(map[string]string{"time": "time"}))
+			assert.Equal(t, tt.want, string(ed.Source()))
+		})
+	}
+}
+
 func countSubstring(s, substr string) int {
 	count := 0
 	for {
@@ -988,3 +1405,899 @@ func countSubstring(s, substr string) int {
 	}
 	return count
 }
+
+func TestEditor_FileSetAndAST(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	original := `package test
+
+type Example struct {
+	ID int64
+}
+`
+	err := os.WriteFile(filePath, []byte(original), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	assert.Same(t, ed.fset, ed.FileSet())
+	assert.Same(t, ed.file, ed.AST())
+
+	ts := ed.AST().Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec)
+	offset := ed.FileSet().Position(ts.Name.Pos()).Offset
+	assert.Equal(t, "Example", string(ed.Source()[offset:offset+len("Example")]))
+
+	// Apply parses the edited source again, so positions follow it.
+	before := ed.FileSet()
+	_, err = ed.EditStruct("Example", map[string]string{"ID": "map[string]int64"})
+	require.NoError(t, err)
+	require.NoError(t, ed.Apply())
+	assert.NotSame(t, before, ed.FileSet())
+
+	field := ed.AST().Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType).Fields.List[0]
+	start, end := ed.FileSet().Position(field.Type.Pos()).Offset, ed.FileSet().Position(field.Type.End()).Offset
+	assert.Equal(t, "map[string]int64", string(ed.Source()[start:end]))
+}
+
+func TestEditor_EditStruct_Generic(t *testing.T) {
+	t.Run("concrete field in generic struct", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Box[T any] struct {
+	Value T
+	Meta  string
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Box"}, ed.StructNames())
+
+		modified, err := ed.EditStruct("Box", map[string]string{"Meta": "[]byte"})
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+
+		src := string(ed.Source())
+		assert.Contains(t, src, "type Box[T any] struct")
+		assert.Contains(t, src, "Value T")
+		assert.Contains(t, src, "Meta  []byte")
+	})
+
+	t.Run("type parameter field to concrete type", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.EditStruct("Pair", map[string]string{"Value": "int64"})
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+
+		src := string(ed.Source())
+		assert.Contains(t, src, "type Pair[K comparable, V any] struct")
+		assert.Contains(t, src, "Key   K")
+		assert.Contains(t, src, "Value int64")
+	})
+}
+
+func TestEditor_EditStruct_QualifiedGenericPointer(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+import "example.com/pkg"
+
+type Example struct {
+	Items  *pkg.List[T]
+	Pairs  []*pkg.Map[string, *pkg.List[int]]
+	Plain  *pkg.List[T]
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	modified, err := ed.EditStruct("Example", map[string]string{
+		"Items": "* pkg.List[ T ]",
+		"Pairs": "[]*pkg.Map[string,*pkg.List[int]]",
+	})
+	require.NoError(t, err)
+	assert.False(t, modified)
+
+	fieldEdits := map[string]string{
+		"Items": "*other.List[time.Time]",
+		"Pairs": "[]*pkg.Map[uuid.UUID, *pkg.List[int64]]",
+		"Plain": "pkg.List[T]",
+	}
+	applied, err := ed.EditStructDetailed("Example", fieldEdits)
+	require.NoError(t, err)
+	assert.Equal(t, []FieldEdit{
+		{Struct: "Example", Field: "Items", OldType: "*pkg.List[T]", NewType: "*other.List[time.Time]"},
+		{Struct: "Example", Field: "Pairs", OldType: "[]*pkg.Map[string, *pkg.List[int]]", NewType: "[]*pkg.Map[uuid.UUID, *pkg.List[int64]]"},
+		{Struct: "Example", Field: "Plain", OldType: "*pkg.List[T]", NewType: "pkg.List[T]"},
+	}, applied)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, map[string]string{"other": "other", "time": "time", "pkg": "pkg", "uuid": "uuid"}, ed.RequiredImports(fieldEdits))
+	assert.Contains(t, string(ed.Source()), `type Example struct {
+	Items *other.List[time.Time]
+	Pairs []*pkg.Map[uuid.UUID, *pkg.List[int64]]
+	Plain pkg.List[T]
+}`)
+}
+
+func TestEditor_BuildConstraint(t *testing.T) {
+	t.Run("go:build line", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`// Copyright notice.
+
+//go:build linux && amd64
+
+package test
+`), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		expr, err := ed.BuildConstraint()
+		require.NoError(t, err)
+		require.NotNil(t, expr)
+		assert.Equal(t, "linux && amd64", expr.String())
+	})
+
+	t.Run("legacy plus build lines", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`// +build linux darwin
+// +build amd64
+
+package test
+`), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		expr, err := ed.BuildConstraint()
+		require.NoError(t, err)
+		require.NotNil(t, expr)
+		assert.Equal(t, "(linux || darwin) && amd64", expr.String())
+	})
+
+	t.Run("unconstrained", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+//go:build ignore
+`), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		expr, err := ed.BuildConstraint()
+		require.NoError(t, err)
+		assert.Nil(t, expr)
+	})
+}
+
+func TestEditor_EditStruct_Normalized(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	original := `package test
+
+type Example struct {
+	Data    map[string]int
+	Created *time.Time
+	Items   []string
+	Events  chan<- int
+	Handler func(int) error
+}
+`
+	err := os.WriteFile(filePath, []byte(original), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	modified, err := ed.EditStruct("Example", map[string]string{
+		"Data":    "map[string] int",
+		"Created": " * time.Time ",
+		"Items":   "[ ]string",
+		"Events":  "chan <- int",
+		"Handler": "func( int )error",
+	})
+	require.NoError(t, err)
+	assert.False(t, modified)
+
+	modified, err = ed.EditStruct("Example", map[string]string{"Data": "map[ string ]int64"})
+	require.NoError(t, err)
+	assert.True(t, modified)
+
+	ed.Apply()
+	assert.Contains(t, string(ed.Source()), "Data    map[string]int64\n")
+}
+
+func TestEditor_EditStruct_EmbeddedSelector(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	original := `package test
+
+import "example.com/pkg"
+
+type Example struct {
+	pkg.Base
+	*pkg.Meta ` + "`json:\"meta\"`" + `
+	ID        int64
+}
+`
+	require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+	ed.SetStrict(true)
+
+	assert.True(t, ed.HasField("Example", "Base"))
+	assert.True(t, ed.HasField("Example", "Meta"))
+
+	edits, err := ed.EditStructDetailed("Example", map[string]string{
+		"Base": "*pkg.Base",
+		"Meta": "*pkg.Meta",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []FieldEdit{{Struct: "Example", Field: "Base", OldType: "pkg.Base", NewType: "*pkg.Base"}}, edits)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+import "example.com/pkg"
+
+type Example struct {
+	*pkg.Base
+	*pkg.Meta `+"`json:\"meta\"`"+`
+	ID        int64
+}
+`, string(ed.Source()))
+}
+
+func TestEditor_EditStruct_UnusualNames(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	original := `package test
+
+type Type string
+
+type Example struct {
+	Type      Type
+	Func      func()
+	Map, Chan string
+	Null      string
+	Größe     int
+	_x        int
+	ǅ         int
+}
+`
+	require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+	ed.SetStrict(true)
+
+	edits, err := ed.EditStructDetailed("Example", map[string]string{
+		"Type":  "*Type",
+		"Func":  "func() error",
+		"Map":   "map[string]int",
+		"Chan":  "chan int",
+		"Null":  "sql.NullString",
+		"Größe": "int64",
+		"_x":    "uint",
+		"ǅ":     "int8",
+	})
+	require.NoError(t, err)
+	assert.Len(t, edits, 8)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Type string
+
+type Example struct {
+	Type  *Type
+	Func  func() error
+	Map   map[string]int
+	Chan  chan int
+	Null  sql.NullString
+	Größe int64
+	_x    uint
+	ǅ     int8
+}
+`, string(ed.Source()))
+}
+
+func TestEditor_EditStruct_ArrayLength(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	original := `package test
+
+type Key struct {
+	Secret [32]byte ` + "`" + `json:"secret"` + "`" + `
+	Nonce  [Size * 2]byte
+}
+`
+	err := os.WriteFile(filePath, []byte(original), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	modified, err := ed.EditStruct("Key", map[string]string{"Nonce": "[Size*2]byte"})
+	require.NoError(t, err)
+	assert.False(t, modified)
+
+	applied, err := ed.EditStructDetailed("Key", map[string]string{"Secret": "[64]byte"})
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, "[32]byte", applied[0].OldType)
+
+	ed.Apply()
+
+	assert.Contains(t, string(ed.Source()), "Secret [64]byte `json:\"secret\"`\n")
+}
+
+func TestEditor_VerifySyntax(t *testing.T) {
+	t.Run("valid edits", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	ID    int64
+	Total *int64
+}
+`), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		_, err = ed.EditStruct("Example", map[string]string{"Total": "time.Time"})
+		require.NoError(t, err)
+		ed.Apply()
+		require.NoError(t, ed.AddImports(map[string]string{"time": "time"}))
+
+		assert.NoError(t, ed.VerifySyntax())
+	})
+
+	t.Run("invalid type names the field", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	ID    int64
+	Total *int64
+	Name  string
+}
+`), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		_, err = ed.EditStruct("Example", map[string]string{"ID": "uint64", "Total": "map[string"})
+		require.NoError(t, err)
+		err = ed.Apply()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "edit of Example.Total")
+
+		err = ed.VerifySyntax()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "edit of Example.Total")
+	})
+}
+
+func TestEditor_EditStruct_Strict(t *testing.T) {
+	src := []byte(`package test
+
+type Example struct {
+	ID int64
+}
+`)
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	require.NoError(t, os.WriteFile(filePath, src, 0644))
+
+	t.Run("missing field ignored by default", func(t *testing.T) {
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		applied, err := ed.EditStructDetailed("Example", map[string]string{"Missing": "string"})
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+	})
+
+	t.Run("missing field", func(t *testing.T) {
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		ed.SetStrict(true)
+
+		_, err = ed.EditStruct("Example", map[string]string{"ID": "uint64", "Missing": "string"})
+		require.ErrorIs(t, err, ErrFieldNotFound)
+		assert.Contains(t, err.Error(), "Example.Missing")
+	})
+
+	t.Run("missing struct", func(t *testing.T) {
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		ed.SetStrict(true)
+
+		_, err = ed.EditStruct("Other", map[string]string{"ID": "uint64"})
+		require.ErrorIs(t, err, ErrStructNotFound)
+	})
+
+	t.Run("existing fields", func(t *testing.T) {
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		ed.SetStrict(true)
+
+		_, err = ed.EditStruct("Example", map[string]string{"ID": "uint64"})
+		require.NoError(t, err)
+		require.NoError(t, ed.Apply())
+		assert.Contains(t, string(ed.Source()), "ID uint64")
+	})
+}
+
+func TestEditor_EditStruct_Duplicates(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	ID int64
+}
+
+type Example struct {
+	ID int32
+}
+`), 0644)
+	require.NoError(t, err)
+
+	t.Run("all", func(t *testing.T) {
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		applied, err := ed.EditStructDetailed("Example", map[string]string{"ID": "uint64"})
+		require.NoError(t, err)
+		assert.Len(t, applied, 2)
+	})
+
+	t.Run("first", func(t *testing.T) {
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		ed.SetDuplicates(DuplicatesFirst)
+
+		applied, err := ed.EditStructDetailed("Example", map[string]string{"ID": "uint64"})
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.Equal(t, "int64", applied[0].OldType)
+		require.NoError(t, ed.Apply())
+		assert.Contains(t, string(ed.Source()), "ID uint64")
+		assert.Contains(t, string(ed.Source()), "ID int32")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		ed.SetDuplicates(DuplicatesError)
+
+		_, err = ed.EditStruct("Example", map[string]string{"ID": "uint64"})
+		require.ErrorIs(t, err, ErrDuplicateType)
+	})
+}
+
+func TestEditor_Format(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	ID int64
+}
+
+func  Untidy( ) {   }
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+	formatted, err := ed.Formatted()
+	require.NoError(t, err)
+	assert.False(t, formatted)
+
+	_, err = ed.EditStruct("Example", map[string]string{"ID": "uint64"})
+	require.NoError(t, err)
+	require.NoError(t, ed.Apply())
+	require.NoError(t, ed.Format())
+
+	assert.Equal(t, `package test
+
+type Example struct {
+	ID uint64
+}
+
+func Untidy() {}
+`, string(ed.Source()))
+	formatted, err = ed.Formatted()
+	require.NoError(t, err)
+	assert.True(t, formatted)
+}
+
+func TestEditor_Apply_Realigns(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Order struct {
+	ID    string `+"`json:\"id\"`"+`    // primary key
+	Total *int64 `+"`json:\"total\"`"+` // in cents
+	Paid  bool   `+"`json:\"paid\"`"+`
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+	_, err = ed.EditStruct("Order", map[string]string{"Total": "uint64", "Paid": "time.Time"})
+	require.NoError(t, err)
+	require.NoError(t, ed.Apply())
+	require.NoError(t, ed.AddImports(map[string]string{"time": "time"}))
+
+	assert.Equal(t, `package test
+
+import (
+	"time"
+)
+
+type Order struct {
+	ID    string    `+"`json:\"id\"`"+`    // primary key
+	Total uint64    `+"`json:\"total\"`"+` // in cents
+	Paid  time.Time `+"`json:\"paid\"`"+`
+}
+`, string(ed.Source()))
+	formatted, err := ed.Formatted()
+	require.NoError(t, err)
+	assert.True(t, formatted)
+}
+
+func TestEditor_VerifyImports(t *testing.T) {
+	write := func(t *testing.T, src string) *Editor {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte(src), 0644))
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		return ed
+	}
+
+	t.Run("used imports", func(t *testing.T) {
+		ed := write(t, `package test
+
+import _ "embed"
+
+type Example struct {
+	CreatedAt string
+}
+`)
+		_, err := ed.EditStruct("Example", map[string]string{"CreatedAt": "time.Time"})
+		require.NoError(t, err)
+		require.NoError(t, ed.Apply())
+		require.NoError(t, ed.AddImports(map[string]string{"time": "time"}))
+
+		assert.NoError(t, ed.VerifyImports())
+	})
+
+	t.Run("unused added import", func(t *testing.T) {
+		ed := write(t, `package test
+
+type Example struct {
+	CreatedAt string
+}
+`)
+		require.NoError(t, ed.AddImports(map[string]string{"time": "time"}))
+
+		err := ed.VerifyImports()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `import "time" is unused`)
+	})
+
+	t.Run("shared local name", func(t *testing.T) {
+		ed := write(t, `package test
+
+import (
+	"crypto/rand"
+	"math/rand"
+)
+
+type Example struct {
+	Source *rand.Rand
+}
+`)
+		err := ed.VerifyImports()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `import "math/rand": name rand is already used by "crypto/rand"`)
+	})
+}
+
+func TestEditor_EditStruct_MixedStruct(t *testing.T) {
+	original := `package test
+
+type Mixed struct {
+	// Base is embedded.
+	Base
+	*pkg.Other ` + "`" + `json:"other"` + "`" + `
+	io.Reader
+
+	// ID is the key.
+	ID int64 ` + "`" + `json:"id"` + "`" + ` // primary
+
+	A, B, C int
+	Name    string ` + "`" + `json:"name"` + "`" + `
+
+	Nested struct {
+		X int
+	}
+	Ptr *int64 // pointer
+}
+`
+	edit := func(t *testing.T, fields map[string]string) (string, []FieldEdit) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+		applied, err := ed.EditStructDetailed("Mixed", fields)
+		require.NoError(t, err)
+		require.NoError(t, ed.Apply())
+		return string(ed.Source()), applied
+	}
+
+	t.Run("single named fields", func(t *testing.T) {
+		for field, change := range map[string][2]string{
+			"ID":   {"ID int64 `", "ID uuid.UUID `"},
+			"Name": {"Name    string `", "Name    []byte `"},
+			"Ptr":  {"Ptr *int64 //", "Ptr *uint64 //"},
+		} {
+			newType := strings.Fields(change[1])[1]
+			src, applied := edit(t, map[string]string{field: newType})
+			require.Len(t, applied, 1, field)
+			assert.Equal(t, strings.Replace(original, change[0], change[1], 1), src, field)
+		}
+	})
+
+	t.Run("embedded fields by type name", func(t *testing.T) {
+		src, applied := edit(t, map[string]string{"Other": "pkg.Other", "Reader": "io.Reader"})
+		assert.Equal(t, []FieldEdit{{Struct: "Mixed", Field: "Other", OldType: "*pkg.Other", NewType: "pkg.Other"}}, applied)
+		assert.Equal(t, strings.Replace(original, "\t*pkg.Other `", "\tpkg.Other `", 1), src)
+	})
+
+	t.Run("whole group", func(t *testing.T) {
+		src, applied := edit(t, map[string]string{"A": "uint", "B": "uint", "C": "uint"})
+		assert.Len(t, applied, 3)
+		assert.Equal(t, strings.Replace(original, "A, B, C int", "A, B, C uint", 1), src)
+	})
+
+	t.Run("one name of a group", func(t *testing.T) {
+		src, applied := edit(t, map[string]string{"B": "string"})
+		assert.Equal(t, []FieldEdit{{Struct: "Mixed", Field: "B", OldType: "int", NewType: "string"}}, applied)
+		assert.Equal(t, strings.Replace(original, "\tA, B, C int\n\tName    string", "\tA, C int\n\tB    string\n\tName string", 1), src)
+	})
+
+	t.Run("group names to different types", func(t *testing.T) {
+		src, applied := edit(t, map[string]string{"A": "int8", "C": "int16"})
+		assert.Len(t, applied, 2)
+		assert.Equal(t, strings.Replace(original, "\tA, B, C int\n\tName    string", "\tB    int\n\tA    int8\n\tC    int16\n\tName string", 1), src)
+	})
+}
+
+func TestEditor_EditStruct_ConstraintInterfaces(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Number interface {
+	~int | ~int64 | float64
+}
+
+type Shape interface {
+	Base | struct{ ID int64 }
+	Area() float64
+}
+
+type Base struct {
+	ID int64
+}
+
+type Holder[T interface{ *Base }, N Number] struct {
+	Item  T
+	Total N
+	Count int
+}
+
+func Sum[T Number](xs []T) (total T) {
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Number", "Shape", "Base", "Holder"}, ed.StructNames())
+
+	for _, name := range []string{"Number", "Shape", "Sum"} {
+		modified, err := ed.EditStruct(name, map[string]string{"ID": "uint64"})
+		require.NoError(t, err)
+		assert.False(t, modified, name)
+	}
+
+	modified, err := ed.EditStruct("Base", map[string]string{"ID": "uint64"})
+	require.NoError(t, err)
+	assert.True(t, modified)
+	modified, err = ed.EditStruct("Holder", map[string]string{"Count": "int64"})
+	require.NoError(t, err)
+	assert.True(t, modified)
+	changed, err := ed.EditInterface("Shape", map[string]string{"Perimeter": "() float64"})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.NoError(t, ed.Apply())
+	require.NoError(t, ed.VerifySyntax())
+
+	src := string(ed.Source())
+	assert.Contains(t, src, "type Base struct {\n\tID uint64\n}")
+	assert.Contains(t, src, "\tCount int64\n")
+	assert.Contains(t, src, "\tBase | struct{ ID int64 }\n\tArea() float64\n\tPerimeter() float64\n")
+	assert.Contains(t, src, "~int | ~int64 | float64")
+}
+
+func TestEditor_OnBeforeWrite(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	original := "package test\n\ntype Example struct {\n\tTotal *int64\n}\n"
+	require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+	_, err = ed.EditStruct("Example", map[string]string{"Total": "uint64"})
+	require.NoError(t, err)
+	require.NoError(t, ed.Apply())
+
+	t.Run("hooks run in order", func(t *testing.T) {
+		var seen string
+		ed.OnBeforeWrite(func(path string, src []byte) ([]byte, error) {
+			seen = path
+			return append([]byte("// Code generated by editstruct. DO NOT EDIT.\n\n"), src...), nil
+		})
+		ed.OnBeforeWrite(func(path string, src []byte) ([]byte, error) {
+			return bytes.ReplaceAll(src, []byte("editstruct"), []byte("tool")), nil
+		})
+		require.NoError(t, ed.WriteTo(filePath))
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, filePath, seen)
+		assert.Equal(t, "// Code generated by tool. DO NOT EDIT.\n\npackage test\n\ntype Example struct {\n\tTotal uint64\n}\n", string(content))
+		assert.NotContains(t, string(ed.Source()), "generated")
+	})
+
+	t.Run("hook error aborts write", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+		ed.OnBeforeWrite(func(string, []byte) ([]byte, error) {
+			return nil, errors.New("formatter failed")
+		})
+		err := ed.WriteTo(filePath)
+		assert.ErrorContains(t, err, "formatter failed")
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+}
+
+func TestEditor_EditStruct_InlineNested(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Customer struct {
+	Name    string
+	Address struct {
+		City string
+		Zip  string `+"`"+`json:"zip"`+"`"+`
+	} `+"`"+`json:"address"`+"`"+`
+	Items []struct{ SKU string }
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+	ed.SetStrict(true)
+	assert.True(t, ed.HasField("Customer", "Address.Zip"))
+	assert.False(t, ed.HasField("Customer", "Name.Zip"))
+
+	applied, err := ed.EditStructDetailed("Customer", map[string]string{"Address.Zip": "int", "Items.SKU": "int64"})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []FieldEdit{
+		{Struct: "Customer.Address", Field: "Zip", OldType: "string", NewType: "int"},
+		{Struct: "Customer.Items", Field: "SKU", OldType: "string", NewType: "int64"},
+	}, applied)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Customer struct {
+	Name    string
+	Address struct {
+		City string
+		Zip  int `+"`"+`json:"zip"`+"`"+`
+	} `+"`"+`json:"address"`+"`"+`
+	Items []struct{ SKU int64 }
+}
+`, string(ed.Source()))
+
+	_, err = ed.EditStruct("Customer", map[string]string{"Address.Street": "string"})
+	assert.ErrorIs(t, err, ErrFieldNotFound)
+}
+
+func TestEditor_EditStruct_PointerSlice(t *testing.T) {
+	shapes := []string{"T", "*T", "[]T", "[]*T", "*[]T", "*[]*T"}
+	spaced := map[string]string{
+		"T":     " T ",
+		"*T":    "* T",
+		"[]T":   "[ ] T",
+		"[]*T":  "[] * T",
+		"*[]T":  "* [] T",
+		"*[]*T": "* [ ] * T",
+	}
+
+	for _, from := range shapes {
+		for _, to := range shapes {
+			t.Run(from+" to "+to, func(t *testing.T) {
+				filePath := filepath.Join(t.TempDir(), "types.go")
+				require.NoError(t, os.WriteFile(filePath, []byte("package test\n\ntype Example struct {\n\tItems "+from+"\n}\n"), 0644))
+				ed, err := ParseFile(filePath)
+				require.NoError(t, err)
+
+				edits, err := ed.EditStructDetailed("Example", map[string]string{"Items": spaced[to]})
+				require.NoError(t, err)
+				if from == to {
+					assert.Empty(t, edits)
+					return
+				}
+				assert.Equal(t, []FieldEdit{{Struct: "Example", Field: "Items", OldType: from, NewType: to}}, edits)
+				require.NoError(t, ed.Apply())
+				assert.Equal(t, "package test\n\ntype Example struct {\n\tItems "+to+"\n}\n", string(ed.Source()))
+			})
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package editor
+
+import "errors"
+
+var (
+	// ErrParse is returned when a source file, or the result of an edit, is not valid Go.
+	ErrParse = errors.New("parse file")
+	// ErrStructNotFound is returned in strict mode when an edited struct does not exist.
+	ErrStructNotFound = errors.New("struct not found")
+	// ErrFieldNotFound is returned in strict mode when an edited field does not exist.
+	ErrFieldNotFound = errors.New("field not found")
+	// ErrDuplicateType is returned when a type is declared more than once and
+	// duplicates are not allowed.
+	ErrDuplicateType = errors.New("duplicate type")
+)
@@ -0,0 +1,873 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+func findField(st *ast.StructType, fieldName string) *ast.Field {
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 && embeddedName(field.Type) == fieldName {
+			return field
+		}
+		for _, name := range field.Names {
+			if name.Name == fieldName {
+				return field
+			}
+		}
+	}
+	return nil
+}
+
+// embeddedName returns the field name of an embedded field: the type name
+// without pointer, package selector or type arguments.
+func embeddedName(expr ast.Expr) string {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.SelectorExpr:
+			return t.Sel.Name
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// AddField appends a field before the closing brace of a struct, indented
+// like the existing fields. tag is the tag content without quotes and may be
+// empty. Structs that already have the field are left alone.
+func (e *Editor) AddField(structName, fieldName, fieldType, tag string) (bool, error) {
+	if tag != "" {
+		if _, err := parseTag(tag); err != nil {
+			return false, err
+		}
+	}
+	line := fieldName + " " + normalizeType(fieldType)
+	if tag != "" {
+		line += " " + tagLiteral(tag)
+	}
+
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		if findField(st, fieldName) != nil {
+			continue
+		}
+		closing := e.offset(st.Fields.Closing)
+		lineStart := bytes.LastIndexByte(e.src[:closing], '\n') + 1
+		owner := structName + "." + fieldName
+		if strings.TrimSpace(string(e.src[lineStart:closing])) == "" && lineStart > e.offset(st.Fields.Opening) {
+			// The closing brace is on a line of its own.
+			indent := e.lineIndent(st.Fields.Closing) + "\t"
+			if len(st.Fields.List) > 0 {
+				indent = e.lineIndent(st.Fields.List[len(st.Fields.List)-1].Pos())
+			}
+			e.edits = append(e.edits, spanEdit{start: lineStart, end: lineStart, text: indent + line + "\n", owner: owner})
+		} else {
+			indent := e.lineIndent(st.Pos())
+			e.edits = append(e.edits, spanEdit{start: closing, end: closing, text: "\n" + indent + "\t" + line + "\n" + indent, owner: owner})
+		}
+		modified = true
+	}
+	return modified, nil
+}
+
+// RemoveField deletes a field from a struct together with its doc comment
+// and line comment. Of a grouped field (`A, B int`) only the named identifier
+// is dropped. Embedded fields are matched by type name. Structs without the
+// field are left alone.
+func (e *Editor) RemoveField(structName, fieldName string) (bool, error) {
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil {
+			continue
+		}
+		owner := structName + "." + fieldName
+		modified = true
+
+		if len(field.Names) > 1 {
+			i := slices.IndexFunc(field.Names, func(id *ast.Ident) bool { return id.Name == fieldName })
+			start, end := e.offset(field.Names[i].Pos()), e.offset(field.Names[i].End())
+			if i+1 < len(field.Names) {
+				end = e.offset(field.Names[i+1].Pos())
+			} else {
+				start = e.offset(field.Names[i-1].End())
+			}
+			e.edits = append(e.edits, spanEdit{start: start, end: end, owner: owner})
+			continue
+		}
+
+		startPos, endPos := field.Pos(), field.End()
+		if field.Doc != nil {
+			startPos = field.Doc.Pos()
+		}
+		if field.Comment != nil {
+			endPos = field.Comment.End()
+		}
+		start, end := e.offset(startPos), e.offset(endPos)
+		lineStart := bytes.LastIndexByte(e.src[:start], '\n') + 1
+		lineEnd := len(e.src)
+		if i := bytes.IndexByte(e.src[end:], '\n'); i >= 0 {
+			lineEnd = end + i
+		}
+		if len(bytes.TrimSpace(e.src[lineStart:start])) == 0 && len(bytes.TrimSpace(e.src[end:lineEnd])) == 0 {
+			// The field has its lines to itself: drop them entirely.
+			start, end = lineStart, min(lineEnd+1, len(e.src))
+		} else {
+			// A field sharing its line, as in struct{ A int; B int }.
+			for end < len(e.src) && (e.src[end] == ' ' || e.src[end] == ';') {
+				end++
+			}
+		}
+		e.edits = append(e.edits, spanEdit{start: start, end: end, owner: owner})
+	}
+	return modified, nil
+}
+
+// RenameField renames a field of a struct, keeping its type, tag and
+// comments. Only the named identifier of a grouped field (`A, B int`) is
+// renamed. It fails if the struct already has a field with the new name.
+func (e *Editor) RenameField(structName, oldName, newName string) (bool, error) {
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, oldName)
+		if field == nil || oldName == newName {
+			continue
+		}
+		if len(field.Names) == 0 {
+			return false, fmt.Errorf("field %s.%s is embedded and cannot be renamed", structName, oldName)
+		}
+		if findField(st, newName) != nil {
+			return false, fmt.Errorf("field %s.%s already exists", structName, newName)
+		}
+		for _, name := range field.Names {
+			if name.Name == oldName {
+				e.edits = append(e.edits, spanEdit{start: e.offset(name.Pos()), end: e.offset(name.End()), text: newName, owner: structName + "." + oldName})
+				modified = true
+			}
+		}
+	}
+	return modified, nil
+}
+
+// EmbeddedType returns the type of the field embedded in a struct under the
+// given name, without pointer or type arguments, e.g. "Base" or "pkg.Base".
+func (e *Editor) EmbeddedType(structName, fieldName string) (string, bool) {
+	for _, st := range e.structTypes(structName) {
+		for _, field := range st.Fields.List {
+			if len(field.Names) > 0 || embeddedName(field.Type) != fieldName {
+				continue
+			}
+			typ := field.Type
+			if star, ok := typ.(*ast.StarExpr); ok {
+				typ = star.X
+			}
+			switch t := typ.(type) {
+			case *ast.IndexExpr:
+				typ = t.X
+			case *ast.IndexListExpr:
+				typ = t.X
+			}
+			return e.typeString(typ), true
+		}
+	}
+	return "", false
+}
+
+// HasField reports whether any struct with the given name has a named field
+// fieldName, which may be a dotted path into inline structs.
+func (e *Editor) HasField(structName, fieldName string) bool {
+	for _, st := range e.structTypes(structName) {
+		if lookupField(st, fieldName) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldNames returns the names of the named fields of a struct in source order.
+func (e *Editor) FieldNames(structName string) []string {
+	var names []string
+	for _, st := range e.structTypes(structName) {
+		for _, field := range st.Fields.List {
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+		}
+	}
+	return names
+}
+
+// FieldAt returns the name of the field at a zero-based position among the
+// named fields of a struct, counting each name of a grouped field. Embedded
+// fields are not counted.
+func (e *Editor) FieldAt(structName string, index int) (string, bool) {
+	structs := e.structTypes(structName)
+	if len(structs) == 0 || index < 0 {
+		return "", false
+	}
+	var names []string
+	for _, field := range structs[0].Fields.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	if index >= len(names) {
+		return "", false
+	}
+	return names[index], true
+}
+
+// Walk calls fn for every named field of every struct type in the file, in
+// source order. Embedded fields are skipped.
+func (e *Editor) Walk(fn func(structName, fieldName, fieldType string)) {
+	for _, decl := range e.file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			for _, field := range st.Fields.List {
+				fieldType := e.typeString(field.Type)
+				for _, name := range field.Names {
+					fn(ts.Name.Name, name.Name, fieldType)
+				}
+			}
+		}
+	}
+}
+
+// ReplaceTypes changes the type of every named field, in every struct of the
+// file including inline ones, whose type is a key of replacements to the
+// corresponding value. Types are compared in normalized form.
+func (e *Editor) ReplaceTypes(replacements map[string]string) []FieldEdit {
+	normalized := make(map[string]string, len(replacements))
+	for from, to := range replacements {
+		normalized[normalizeType(from)] = normalizeType(to)
+	}
+
+	var applied []FieldEdit
+	for _, decl := range e.file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			ast.Inspect(ts.Type, func(n ast.Node) bool {
+				switch n.(type) {
+				case *ast.FuncType, *ast.InterfaceType:
+					// Parameters and methods are not struct fields.
+					return false
+				}
+				field, ok := n.(*ast.Field)
+				if !ok || len(field.Names) == 0 {
+					return true
+				}
+				oldType := e.typeString(field.Type)
+				newType, ok := normalized[oldType]
+				if !ok || newType == oldType {
+					return true
+				}
+				for _, name := range field.Names {
+					applied = append(applied, FieldEdit{Struct: ts.Name.Name, Field: name.Name, OldType: oldType, NewType: newType})
+				}
+				e.replaceFieldType(field, applied[len(applied)-1])
+				return false
+			})
+		}
+	}
+	return applied
+}
+
+// SetLineComment inserts or replaces the trailing comment of a field, placed
+// after its type and tag.
+func (e *Editor) SetLineComment(structName, fieldName, text string) (bool, error) {
+	var modified bool
+	comment := "// " + text
+
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil {
+			continue
+		}
+
+		if field.Comment != nil {
+			start := e.offset(field.Comment.Pos())
+			end := e.offset(field.Comment.End())
+			if string(e.src[start:end]) == comment {
+				continue
+			}
+			e.edits = append(e.edits, spanEdit{start: start, end: end, text: comment, owner: structName + "." + fieldName})
+		} else {
+			end := e.offset(field.End())
+			e.edits = append(e.edits, spanEdit{start: end, end: end, text: " " + comment, owner: structName + "." + fieldName})
+		}
+		modified = true
+	}
+
+	return modified, nil
+}
+
+// SetFieldLine replaces everything after the field name (type, tag and trailing
+// comment) with spec. A field declared together with other names is split out
+// of the group first. It replaces the whole field span, so it must not be
+// combined with other edits of the same field.
+func (e *Editor) SetFieldLine(structName, fieldName, spec string) (bool, error) {
+	var modified bool
+
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil || len(field.Names) == 0 {
+			continue
+		}
+
+		end := e.offset(field.End())
+		if field.Comment != nil {
+			end = e.offset(field.Comment.End())
+		}
+
+		if len(field.Names) == 1 {
+			start := e.offset(field.Names[0].End())
+			if strings.TrimSpace(string(e.src[start:end])) == spec {
+				continue
+			}
+			e.edits = append(e.edits, spanEdit{start: start, end: end, text: " " + spec, owner: structName + "." + fieldName})
+			modified = true
+			continue
+		}
+
+		var rest []string
+		for _, name := range field.Names {
+			if name.Name != fieldName {
+				rest = append(rest, name.Name)
+			}
+		}
+		start := e.offset(field.Pos())
+		tail := string(e.src[e.offset(field.Type.Pos()):end])
+		text := strings.Join(rest, ", ") + " " + tail + "\n" + e.lineIndent(field.Pos()) + fieldName + " " + spec
+		e.edits = append(e.edits, spanEdit{start: start, end: end, text: text, owner: structName + "." + fieldName})
+		modified = true
+	}
+
+	return modified, nil
+}
+
+// SortFields reorders the fields of a struct alphabetically by name, keeping
+// doc and trailing comments with their fields. Embedded fields stay at the top
+// in their original order. Blank lines between fields are dropped. Structs with
+// several fields on one line or with comments not attached to a field are
+// rejected.
+func (e *Editor) SortFields(structName string) (bool, error) {
+	var modified bool
+
+	for _, st := range e.structTypes(structName) {
+		if len(st.Fields.List) < 2 {
+			continue
+		}
+
+		type chunk struct {
+			name       string
+			embedded   bool
+			start, end int
+		}
+		chunks := make([]chunk, 0, len(st.Fields.List))
+		for i, field := range st.Fields.List {
+			first := field.Pos()
+			if field.Doc != nil {
+				first = field.Doc.Pos()
+			}
+			last := field.End()
+			if field.Comment != nil {
+				last = field.Comment.End()
+			}
+			c := chunk{
+				embedded: len(field.Names) == 0,
+				start:    e.offset(first) - len(e.lineIndent(first)),
+				end:      e.offset(last),
+			}
+			if !c.embedded {
+				c.name = field.Names[0].Name
+			}
+			lineEnd := bytes.IndexByte(e.src[c.end:], '\n')
+			if c.start > 0 && e.src[c.start-1] != '\n' || lineEnd < 0 || strings.TrimSpace(string(e.src[c.end:c.end+lineEnd])) != "" {
+				return false, fmt.Errorf("struct %s: fields must be declared on separate lines", structName)
+			}
+			if i > 0 && strings.TrimSpace(string(e.src[chunks[i-1].end:c.start])) != "" {
+				return false, fmt.Errorf("struct %s: comments between fields are not supported", structName)
+			}
+			chunks = append(chunks, c)
+		}
+
+		sorted := slices.Clone(chunks)
+		slices.SortStableFunc(sorted, func(a, b chunk) int {
+			if a.embedded || b.embedded {
+				if a.embedded && b.embedded {
+					return 0
+				}
+				if a.embedded {
+					return -1
+				}
+				return 1
+			}
+			return strings.Compare(a.name, b.name)
+		})
+
+		changed := false
+		lines := make([]string, len(sorted))
+		for i, c := range sorted {
+			if c != chunks[i] {
+				changed = true
+			}
+			lines[i] = string(e.src[c.start:c.end])
+		}
+		if !changed {
+			continue
+		}
+
+		start, end := chunks[0].start, chunks[len(chunks)-1].end
+		e.edits = append(e.edits, spanEdit{start: start, end: end, text: strings.Join(lines, "\n"), owner: structName})
+		modified = true
+	}
+
+	return modified, nil
+}
+
+func chanPrefix(dir ast.ChanDir) string {
+	switch dir {
+	case ast.SEND:
+		return "chan<- "
+	case ast.RECV:
+		return "<-chan "
+	default:
+		return "chan "
+	}
+}
+
+// SetChanDir changes the direction of a channel field to "recv", "send" or
+// "both", keeping the element type as written.
+func (e *Editor) SetChanDir(structName, fieldName, dir string) (bool, error) {
+	var want ast.ChanDir
+	switch dir {
+	case "recv":
+		want = ast.RECV
+	case "send":
+		want = ast.SEND
+	case "both":
+		want = ast.SEND | ast.RECV
+	default:
+		return false, fmt.Errorf("invalid channel direction %q", dir)
+	}
+
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil {
+			continue
+		}
+		ct, ok := field.Type.(*ast.ChanType)
+		if !ok {
+			return false, fmt.Errorf("field %s.%s is not a channel", structName, fieldName)
+		}
+		if ct.Dir == want {
+			continue
+		}
+		start := e.offset(ct.Begin)
+		end := e.offset(ct.Value.Pos())
+		e.edits = append(e.edits, spanEdit{start: start, end: end, text: chanPrefix(want), owner: structName + "." + fieldName})
+		modified = true
+	}
+
+	return modified, nil
+}
+
+// SetMapKey replaces the key type of a map field, keeping the value type,
+// tag and comments as written.
+func (e *Editor) SetMapKey(structName, fieldName, keyType string) (bool, error) {
+	keyType = normalizeType(keyType)
+
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil {
+			continue
+		}
+		mt, ok := field.Type.(*ast.MapType)
+		if !ok {
+			return false, fmt.Errorf("field %s.%s is not a map", structName, fieldName)
+		}
+		if e.typeString(mt.Key) == keyType {
+			continue
+		}
+		start := e.offset(mt.Key.Pos())
+		end := e.offset(mt.Key.End())
+		e.edits = append(e.edits, spanEdit{start: start, end: end, text: keyType, owner: structName + "." + fieldName})
+		modified = true
+	}
+
+	return modified, nil
+}
+
+// SetChanElem replaces the element type of a channel field, keeping its
+// direction as written.
+func (e *Editor) SetChanElem(structName, fieldName, elemType string) (bool, error) {
+	elemType = normalizeType(elemType)
+
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil {
+			continue
+		}
+		ct, ok := field.Type.(*ast.ChanType)
+		if !ok {
+			return false, fmt.Errorf("field %s.%s is not a channel", structName, fieldName)
+		}
+		if e.typeString(ct.Value) == elemType {
+			continue
+		}
+		start := e.offset(ct.Value.Pos())
+		end := e.offset(ct.Value.End())
+		e.edits = append(e.edits, spanEdit{start: start, end: end, text: elemType, owner: structName + "." + fieldName})
+		modified = true
+	}
+
+	return modified, nil
+}
+
+// SetFuncParam replaces the type of the parameter at a zero-based position of
+// a func-typed field. A parameter declared together with others (`a, b int`)
+// cannot be changed on its own.
+func (e *Editor) SetFuncParam(structName, fieldName string, index int, typ string) (bool, error) {
+	return e.setFuncType(structName, fieldName, "parameter", index, typ, func(ft *ast.FuncType) *ast.FieldList { return ft.Params })
+}
+
+// SetFuncResult is like SetFuncParam for results. A single unnamed result
+// can be replaced, but a function without results has none to change.
+func (e *Editor) SetFuncResult(structName, fieldName string, index int, typ string) (bool, error) {
+	return e.setFuncType(structName, fieldName, "result", index, typ, func(ft *ast.FuncType) *ast.FieldList { return ft.Results })
+}
+
+func (e *Editor) setFuncType(structName, fieldName, kind string, index int, typ string, list func(*ast.FuncType) *ast.FieldList) (bool, error) {
+	typ = normalizeType(typ)
+
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil {
+			continue
+		}
+		ft, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			return false, fmt.Errorf("field %s.%s is not a func", structName, fieldName)
+		}
+		target := funcListEntry(list(ft), index)
+		if target == nil {
+			return false, fmt.Errorf("field %s.%s has no %s %d", structName, fieldName, kind, index)
+		}
+		if e.typeString(target.Type) == typ {
+			continue
+		}
+		if len(target.Names) > 1 {
+			return false, fmt.Errorf("field %s.%s: %s %d shares its type with other %ss", structName, fieldName, kind, index, kind)
+		}
+		start := e.offset(target.Type.Pos())
+		end := e.offset(target.Type.End())
+		e.edits = append(e.edits, spanEdit{start: start, end: end, text: typ, owner: structName + "." + fieldName})
+		modified = true
+	}
+
+	return modified, nil
+}
+
+// funcListEntry returns the declaration of the parameter or result at a
+// zero-based position, counting each name of a group.
+func funcListEntry(list *ast.FieldList, index int) *ast.Field {
+	if list == nil || index < 0 {
+		return nil
+	}
+	for _, field := range list.List {
+		n := max(len(field.Names), 1)
+		if index < n {
+			return field
+		}
+		index -= n
+	}
+	return nil
+}
+
+// UnwrapPointer turns a pointer field into a value field (`*string` into
+// `string`) and merges the key:"value" pairs of tag into its struct tag, as a
+// single edit. Either part is skipped when there is nothing to do.
+func (e *Editor) UnwrapPointer(structName, fieldName, tag string) (bool, error) {
+	add, err := parseTag(tag)
+	if err != nil {
+		return false, err
+	}
+
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil {
+			continue
+		}
+		if len(field.Names) > 1 {
+			return false, fmt.Errorf("field %s.%s is declared together with other fields", structName, fieldName)
+		}
+
+		typ := field.Type
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ = star.X
+		}
+		pairs, err := fieldTag(field)
+		if err != nil {
+			return false, fmt.Errorf("field %s.%s: %w", structName, fieldName, err)
+		}
+
+		text := string(e.src[e.offset(typ.Pos()):e.offset(typ.End())])
+		if merged := mergeTag(pairs, add); len(merged) > 0 {
+			text += " " + tagLiteral(formatTag(merged))
+		}
+		start := e.offset(field.Type.Pos())
+		end := e.offset(field.Type.End())
+		if field.Tag != nil {
+			end = e.offset(field.Tag.End())
+		}
+		if string(e.src[start:end]) == text {
+			continue
+		}
+		e.edits = append(e.edits, spanEdit{start: start, end: end, text: text, owner: structName + "." + fieldName})
+		modified = true
+	}
+
+	return modified, nil
+}
+
+// SetTags sets several keys of a field's struct tag at once. Keys already in
+// the tag keep their position; new keys are appended in alphabetical order. A
+// field without a tag gets one.
+func (e *Editor) SetTags(structName, fieldName string, tags map[string]string) (bool, error) {
+	add, err := tagPairs(tags)
+	if err != nil {
+		return false, err
+	}
+
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil {
+			continue
+		}
+		if len(field.Names) > 1 {
+			return false, fmt.Errorf("field %s.%s is declared together with other fields", structName, fieldName)
+		}
+		pairs, err := fieldTag(field)
+		if err != nil {
+			return false, fmt.Errorf("field %s.%s: %w", structName, fieldName, err)
+		}
+
+		if e.replaceTag(field, structName+"."+fieldName, formatTag(mergeTag(pairs, add))) {
+			modified = true
+		}
+	}
+
+	return modified, nil
+}
+
+// ReplaceTags sets a field's struct tag to exactly the given keys, in
+// alphabetical order, dropping the keys not listed. An empty map removes the
+// tag.
+func (e *Editor) ReplaceTags(structName, fieldName string, tags map[string]string) (bool, error) {
+	pairs, err := tagPairs(tags)
+	if err != nil {
+		return false, err
+	}
+	return e.SetFieldTag(structName, fieldName, formatTag(pairs))
+}
+
+// tagPairs converts a map of struct tag keys to values into pairs sorted by
+// key, rejecting keys that cannot appear in a tag.
+func tagPairs(tags map[string]string) ([]tagPair, error) {
+	pairs := make([]tagPair, 0, len(tags))
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		if key == "" || strings.ContainsAny(key, " :\"`") {
+			return nil, fmt.Errorf("invalid struct tag key %q", key)
+		}
+		pairs = append(pairs, tagPair{key: key, value: tags[key]})
+	}
+	return pairs, nil
+}
+
+// SetFieldTag replaces the whole struct tag of a field, or gives a field
+// without a tag one. tag is the tag content without quotes; an empty tag
+// removes it.
+func (e *Editor) SetFieldTag(structName, fieldName, tag string) (bool, error) {
+	if _, err := parseTag(tag); err != nil {
+		return false, err
+	}
+
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil {
+			continue
+		}
+		if len(field.Names) > 1 {
+			return false, fmt.Errorf("field %s.%s is declared together with other fields", structName, fieldName)
+		}
+		if e.replaceTag(field, structName+"."+fieldName, tag) {
+			modified = true
+		}
+	}
+
+	return modified, nil
+}
+
+// replaceTag queues an edit setting a field's tag to content, unless it
+// already has that tag.
+func (e *Editor) replaceTag(field *ast.Field, owner, content string) bool {
+	text := tagLiteral(content)
+	if content == "" {
+		if field.Tag == nil {
+			return false
+		}
+		start, end := e.offset(field.Type.End()), e.offset(field.Tag.End())
+		e.edits = append(e.edits, spanEdit{start: start, end: end, owner: owner})
+		return true
+	}
+	if field.Tag == nil {
+		end := e.offset(field.Type.End())
+		e.edits = append(e.edits, spanEdit{start: end, end: end, text: " " + text, owner: owner})
+		return true
+	}
+	if current, err := strconv.Unquote(field.Tag.Value); err == nil && current == content {
+		return false
+	}
+	e.edits = append(e.edits, spanEdit{start: e.offset(field.Tag.Pos()), end: e.offset(field.Tag.End()), text: text, owner: owner})
+	return true
+}
+
+// DropTag removes a field's struct tag. Fields without a tag are left alone.
+func (e *Editor) DropTag(structName, fieldName string) (bool, error) {
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil || field.Tag == nil {
+			continue
+		}
+		if len(field.Names) > 1 {
+			return false, fmt.Errorf("field %s.%s is declared together with other fields", structName, fieldName)
+		}
+		start, end := e.offset(field.Type.End()), e.offset(field.Tag.End())
+		e.edits = append(e.edits, spanEdit{start: start, end: end, owner: structName + "." + fieldName})
+		modified = true
+	}
+
+	return modified, nil
+}
+
+// fieldTag returns the key:"value" pairs of a field's struct tag.
+func fieldTag(field *ast.Field) ([]tagPair, error) {
+	if field.Tag == nil {
+		return nil, nil
+	}
+	content, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return nil, fmt.Errorf("tag: %w", err)
+	}
+	return parseTag(content)
+}
+
+// SetSlice wraps a field's type in a slice (`string` into `[]string`) or,
+// with slice false, unwraps a slice (`[]string` into `string`). Fields already
+// in the wanted shape are left alone.
+func (e *Editor) SetSlice(structName, fieldName string, slice bool) (bool, error) {
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		field := findField(st, fieldName)
+		if field == nil {
+			continue
+		}
+		at, isSlice := field.Type.(*ast.ArrayType)
+		isSlice = isSlice && at.Len == nil
+		if isSlice == slice {
+			continue
+		}
+		if len(field.Names) > 1 {
+			return false, fmt.Errorf("field %s.%s is declared together with other fields", structName, fieldName)
+		}
+		owner := structName + "." + fieldName
+		start := e.offset(field.Type.Pos())
+		if slice {
+			e.edits = append(e.edits, spanEdit{start: start, end: start, text: "[]", owner: owner})
+		} else {
+			e.edits = append(e.edits, spanEdit{start: start, end: e.offset(at.Elt.Pos()), owner: owner})
+		}
+		modified = true
+	}
+
+	return modified, nil
+}
+
+// SetEmbeddedPointer switches an embedded field between value (`Base`) and
+// pointer (`*Base`) embedding. The field is matched by its type name, with or
+// without the package selector.
+func (e *Editor) SetEmbeddedPointer(structName, typeName string, pointer bool) (bool, error) {
+	var modified bool
+	for _, st := range e.structTypes(structName) {
+		for _, field := range st.Fields.List {
+			if len(field.Names) > 0 || !embeddedNameMatches(e.typeString(field.Type), typeName) {
+				continue
+			}
+			star, isPointer := field.Type.(*ast.StarExpr)
+			switch {
+			case pointer && !isPointer:
+				start := e.offset(field.Type.Pos())
+				e.edits = append(e.edits, spanEdit{start: start, end: start, text: "*", owner: structName + "." + typeName})
+			case !pointer && isPointer:
+				start, end := e.offset(star.Star), e.offset(star.X.Pos())
+				e.edits = append(e.edits, spanEdit{start: start, end: end, owner: structName + "." + typeName})
+			default:
+				continue
+			}
+			modified = true
+		}
+	}
+	return modified, nil
+}
+
+func embeddedNameMatches(fieldType, typeName string) bool {
+	fieldType = strings.TrimPrefix(fieldType, "*")
+	if i := strings.IndexByte(fieldType, '['); i >= 0 {
+		fieldType = fieldType[:i]
+	}
+	if fieldType == typeName {
+		return true
+	}
+	_, name, ok := strings.Cut(fieldType, ".")
+	return ok && name == typeName
+}
@@ -0,0 +1,1020 @@
+package editor
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditor_SetLineComment(t *testing.T) {
+	t.Run("add comment to tagged field", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Total *int64 ` + "`" + `json:"total"` + "`" + `
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetLineComment("Example", "Total", "deprecated")
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		modified, err = ed.EditStruct("Example", map[string]string{"Total": "uint64"})
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+
+		assert.Contains(t, string(ed.Source()), "Total uint64 `json:\"total\"` // deprecated\n")
+	})
+
+	t.Run("replace existing comment", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Total int64 // old
+	Count int
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetLineComment("Example", "Total", "deprecated")
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+
+		src := string(ed.Source())
+		assert.Contains(t, src, "Total int64 // deprecated\n")
+		assert.NotContains(t, src, "old")
+		assert.Contains(t, src, "\tCount int\n")
+	})
+
+	t.Run("add tag and comment to bare field", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Zed string
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetTags("Example", "Zed", map[string]string{"json": "zed"})
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		modified, err = ed.SetLineComment("Example", "Zed", "renamed")
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		require.NoError(t, ed.Apply())
+
+		assert.Contains(t, string(ed.Source()), "Zed string `json:\"zed\"` // renamed\n")
+	})
+
+	t.Run("same comment no change", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Total int64 // deprecated
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetLineComment("Example", "Total", "deprecated")
+		require.NoError(t, err)
+		assert.False(t, modified)
+
+		modified, err = ed.SetLineComment("Example", "Missing", "deprecated")
+		require.NoError(t, err)
+		assert.False(t, modified)
+	})
+}
+
+func TestEditor_SetFieldLine(t *testing.T) {
+	t.Run("replace type tag and comment", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Total *int64 ` + "`" + `json:"total"` + "`" + ` // old
+	Count int
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetFieldLine("Example", "Total", "uint64 `json:\"total,omitempty\"` // new")
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+
+		assert.Equal(t, `package test
+
+type Example struct {
+	Total uint64 `+"`"+`json:"total,omitempty"`+"`"+` // new
+	Count int
+}
+`, string(ed.Source()))
+	})
+
+	t.Run("split grouped declaration", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	A, B, C int // shared
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetFieldLine("Example", "B", "string")
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+
+		assert.Equal(t, `package test
+
+type Example struct {
+	A, C int // shared
+	B    string
+}
+`, string(ed.Source()))
+	})
+
+	t.Run("same line no change", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Total int64 // total
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetFieldLine("Example", "Total", "int64 // total")
+		require.NoError(t, err)
+		assert.False(t, modified)
+	})
+}
+
+func TestEditor_SortFields(t *testing.T) {
+	t.Run("alphabetical with comments and tags", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Zeta  string ` + "`" + `json:"zeta"` + "`" + `
+	// Alpha is first.
+	Alpha int // trailing
+	Base
+
+	Mid, Other bool
+	*Extra
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SortFields("Example")
+		require.NoError(t, err)
+		assert.True(t, modified)
+		require.NoError(t, ed.Apply())
+
+		expected := `package test
+
+type Example struct {
+	Base
+	*Extra
+	// Alpha is first.
+	Alpha      int // trailing
+	Mid, Other bool
+	Zeta       string ` + "`" + `json:"zeta"` + "`" + `
+}
+`
+		assert.Equal(t, expected, string(ed.Source()))
+
+		modified, err = ed.SortFields("Example")
+		require.NoError(t, err)
+		assert.False(t, modified)
+	})
+
+	t.Run("composes with type edits", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	B *int64
+	A string
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		_, err = ed.EditStruct("Example", map[string]string{"B": "uint64"})
+		require.NoError(t, err)
+		require.NoError(t, ed.Apply())
+
+		_, err = ed.SortFields("Example")
+		require.NoError(t, err)
+		require.NoError(t, ed.Apply())
+
+		assert.Contains(t, string(ed.Source()), "\tA string\n\tB uint64\n")
+	})
+
+	t.Run("conflicting edits", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	B *int64
+	A string
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		_, err = ed.EditStruct("Example", map[string]string{"B": "uint64"})
+		require.NoError(t, err)
+		_, err = ed.SortFields("Example")
+		require.NoError(t, err)
+
+		err = ed.Apply()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "conflicting edits")
+	})
+
+	t.Run("fields on one line", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte("package test\n\ntype Example struct{ B int; A int }\n"), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		_, err = ed.SortFields("Example")
+		require.Error(t, err)
+	})
+}
+
+func TestEditor_SetChanDir(t *testing.T) {
+	original := `package test
+
+type Example struct {
+	Events  chan Event ` + "`" + `json:"-"` + "`" + `
+	Results <-chan map[string]int
+	Name    string
+}
+`
+
+	t.Run("receive only", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetChanDir("Example", "Events", "recv")
+		require.NoError(t, err)
+		assert.True(t, modified)
+		modified, err = ed.SetChanDir("Example", "Results", "recv")
+		require.NoError(t, err)
+		assert.False(t, modified)
+		require.NoError(t, ed.Apply())
+
+		assert.Contains(t, string(ed.Source()), "Events  <-chan Event `json:\"-\"`\n")
+	})
+
+	t.Run("send only", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetChanDir("Example", "Events", "send")
+		require.NoError(t, err)
+		assert.True(t, modified)
+		modified, err = ed.SetChanDir("Example", "Results", "send")
+		require.NoError(t, err)
+		assert.True(t, modified)
+		require.NoError(t, ed.Apply())
+
+		src := string(ed.Source())
+		assert.Contains(t, src, "Events  chan<- Event `json:\"-\"`\n")
+		assert.Contains(t, src, "Results chan<- map[string]int\n")
+	})
+
+	t.Run("not a channel", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		_, err = ed.SetChanDir("Example", "Name", "recv")
+		require.Error(t, err)
+		_, err = ed.SetChanDir("Example", "Events", "sideways")
+		require.Error(t, err)
+	})
+}
+
+func TestEditor_EditStruct_DecoratedField(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	// ID is the primary key.
+	ID    int64  `+"`"+`json:"id"`+"`"+`   // immutable
+	Name  string `+"`"+`json:"name"`+"`"+` // display name
+	Total *int64 `+"`"+`json:"total"`+"`"+`
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	_, err = ed.EditStruct("Example", map[string]string{"ID": "uuid.UUID"})
+	require.NoError(t, err)
+	require.NoError(t, ed.Apply())
+
+	expected := `package test
+
+type Example struct {
+	// ID is the primary key.
+	ID    uuid.UUID ` + "`" + `json:"id"` + "`" + `   // immutable
+	Name  string    ` + "`" + `json:"name"` + "`" + ` // display name
+	Total *int64    ` + "`" + `json:"total"` + "`" + `
+}
+`
+	assert.Equal(t, expected, string(ed.Source()))
+
+	formatted, err := format.Source(ed.Source())
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(formatted))
+}
+
+func TestEditor_Walk(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Base struct{}
+
+type (
+	Order struct {
+		Base
+		ID        int64
+		A, B      *string
+		CreatedAt time.Time `+"`"+`json:"created_at"`+"`"+`
+	}
+	Status int
+)
+
+type Item struct {
+	Tags map[string][]byte
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	var visited []string
+	ed.Walk(func(structName, fieldName, fieldType string) {
+		visited = append(visited, structName+"."+fieldName+" "+fieldType)
+	})
+	assert.Equal(t, []string{
+		"Order.ID int64",
+		"Order.A *string",
+		"Order.B *string",
+		"Order.CreatedAt time.Time",
+		"Item.Tags map[string][]byte",
+	}, visited)
+}
+
+func TestEditor_SetMapKey(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Index map[string][]*Item `+"`"+`json:"index"`+"`"+` // by name
+	Name  string
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	changed, err := ed.SetMapKey("Example", "Index", "int64")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.NoError(t, ed.Apply())
+	assert.Contains(t, string(ed.Source()), "\tIndex map[int64][]*Item `json:\"index\"` // by name\n")
+
+	changed, err = ed.SetMapKey("Example", "Index", "int64")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	_, err = ed.SetMapKey("Example", "Name", "int64")
+	assert.ErrorContains(t, err, "field Example.Name is not a map")
+}
+
+func TestEditor_SetChanElem(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Events <-chan *Event // incoming
+	Name   string
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	changed, err := ed.SetChanElem("Example", "Events", "event.Envelope")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.NoError(t, ed.Apply())
+	assert.Contains(t, string(ed.Source()), "\tEvents <-chan event.Envelope // incoming\n")
+
+	changed, err = ed.SetChanElem("Example", "Events", "event.Envelope")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	_, err = ed.SetChanElem("Example", "Name", "int")
+	assert.ErrorContains(t, err, "field Example.Name is not a channel")
+}
+
+func TestEditor_SetFuncParam(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Handler func(ctx Context, a, b int, opts ...Option) error
+	Lookup  func(string) (int, bool)
+	Name    string
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	changed, err := ed.SetFuncParam("Example", "Handler", 0, "context.Context")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = ed.SetFuncParam("Example", "Handler", 3, "...func(*Config)")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = ed.SetFuncResult("Example", "Lookup", 0, "int64")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = ed.SetFuncParam("Example", "Handler", 2, "int")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Example struct {
+	Handler func(ctx context.Context, a, b int, opts ...func(*Config)) error
+	Lookup  func(string) (int64, bool)
+	Name    string
+}
+`, string(ed.Source()))
+
+	_, err = ed.SetFuncParam("Example", "Handler", 1, "string")
+	assert.ErrorContains(t, err, "parameter 1 shares its type with other parameters")
+	_, err = ed.SetFuncResult("Example", "Handler", 1, "string")
+	assert.ErrorContains(t, err, "field Example.Handler has no result 1")
+	_, err = ed.SetFuncResult("Example", "Name", 0, "string")
+	assert.ErrorContains(t, err, "field Example.Name is not a func")
+}
+
+func TestEditor_UnwrapPointer(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	X     *string `+"`"+`json:"x"`+"`"+` // nullable
+	Count *int
+	Name  string `+"`"+`validate:"required"`+"`"+`
+	A, B  *int
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	for _, fieldName := range []string{"X", "Count"} {
+		changed, err := ed.UnwrapPointer("Example", fieldName, `validate:"required"`)
+		require.NoError(t, err)
+		assert.True(t, changed, fieldName)
+	}
+	changed, err := ed.UnwrapPointer("Example", "Name", `validate:"required"`)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Example struct {
+	X     string `+"`"+`json:"x" validate:"required"`+"`"+` // nullable
+	Count int    `+"`"+`validate:"required"`+"`"+`
+	Name  string `+"`"+`validate:"required"`+"`"+`
+	A, B  *int
+}
+`, string(ed.Source()))
+
+	_, err = ed.UnwrapPointer("Example", "A", "")
+	assert.ErrorContains(t, err, "field Example.A is declared together with other fields")
+	_, err = ed.UnwrapPointer("Example", "X", "validate")
+	assert.ErrorContains(t, err, "malformed struct tag")
+}
+
+func TestEditor_SetTags(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Total int64 `+"`"+`json:"total"`+"`"+` // in cents
+	Name  string // display name
+	ID    string `+"`"+`db:"id" json:"id"`+"`"+`
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	changed, err := ed.SetTags("Example", "Total", map[string]string{"json": "total,omitempty", "db": "total"})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = ed.SetTags("Example", "Name", map[string]string{"yaml": "name", "json": "name"})
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = ed.SetTags("Example", "ID", map[string]string{"json": "id"})
+	require.NoError(t, err)
+	assert.False(t, changed)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Example struct {
+	Total int64  `+"`"+`json:"total,omitempty" db:"total"`+"`"+` // in cents
+	Name  string `+"`"+`json:"name" yaml:"name"`+"`"+`           // display name
+	ID    string `+"`"+`db:"id" json:"id"`+"`"+`
+}
+`, string(ed.Source()))
+
+	_, err = ed.SetTags("Example", "ID", map[string]string{"bad key": "x"})
+	assert.ErrorContains(t, err, `invalid struct tag key "bad key"`)
+}
+
+func TestEditor_ReplaceTags(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Total int64 `+"`"+`json:"total" db:"total"`+"`"+` // in cents
+	Name  string
+	ID    string `+"`"+`json:"id"`+"`"+`
+	Note  string `+"`"+`json:"note"`+"`"+`
+}
+`), 0644))
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	for field, tags := range map[string]map[string]string{
+		"Total": {"json": "total,omitempty"},
+		"Name":  {"yaml": "name", "json": "name"},
+		"ID":    {"json": "id"},
+		"Note":  {},
+	} {
+		_, err := ed.ReplaceTags("Example", field, tags)
+		require.NoError(t, err)
+	}
+	_, err = ed.ReplaceTags("Example", "ID", map[string]string{"bad key": "x"})
+	assert.ErrorContains(t, err, `invalid struct tag key "bad key"`)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Example struct {
+	Total int64  `+"`"+`json:"total,omitempty"`+"`"+` // in cents
+	Name  string `+"`"+`json:"name" yaml:"name"`+"`"+`
+	ID    string `+"`"+`json:"id"`+"`"+`
+	Note  string
+}
+`, string(ed.Source()))
+}
+
+func TestEditor_SetSlice(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	original := `package test
+
+type Example struct {
+	Name string ` + "`json:\"name\"`" + `
+	Tags []string
+	Hash [32]byte
+	A, B int
+}
+`
+	err := os.WriteFile(filePath, []byte(original), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	changed, err := ed.SetSlice("Example", "Name", true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = ed.SetSlice("Example", "Hash", true)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = ed.SetSlice("Example", "Tags", true)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	require.NoError(t, ed.Apply())
+
+	src := string(ed.Source())
+	assert.Contains(t, src, "\tName []string `json:\"name\"`\n")
+	assert.Contains(t, src, "\tHash [][32]byte\n")
+
+	for _, fieldName := range []string{"Name", "Hash"} {
+		changed, err = ed.SetSlice("Example", fieldName, false)
+		require.NoError(t, err)
+		assert.True(t, changed)
+	}
+	require.NoError(t, ed.Apply())
+	assert.Equal(t, original, string(ed.Source()))
+
+	_, err = ed.SetSlice("Example", "A", true)
+	assert.ErrorContains(t, err, "field Example.A is declared together with other fields")
+}
+
+func TestEditor_ReplaceTypes(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Point struct {
+	X, Y    float32
+	Label   string
+	Handler func(scale float32) float32
+}
+
+type Shape struct {
+	Area   float32 `+"`"+`json:"area"`+"`"+`
+	Center struct {
+		Z float32
+	}
+	Created string
+}
+
+type Scaler interface {
+	Scale(f float32)
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	applied := ed.ReplaceTypes(map[string]string{"float32": "float64", "string": "time.Time", "int": "int64"})
+	assert.Equal(t, []FieldEdit{
+		{Struct: "Point", Field: "X", OldType: "float32", NewType: "float64"},
+		{Struct: "Point", Field: "Y", OldType: "float32", NewType: "float64"},
+		{Struct: "Point", Field: "Label", OldType: "string", NewType: "time.Time"},
+		{Struct: "Shape", Field: "Area", OldType: "float32", NewType: "float64"},
+		{Struct: "Shape", Field: "Z", OldType: "float32", NewType: "float64"},
+		{Struct: "Shape", Field: "Created", OldType: "string", NewType: "time.Time"},
+	}, applied)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Point struct {
+	X, Y    float64
+	Label   time.Time
+	Handler func(scale float32) float32
+}
+
+type Shape struct {
+	Area   float64 `+"`"+`json:"area"`+"`"+`
+	Center struct {
+		Z float64
+	}
+	Created time.Time
+}
+
+type Scaler interface {
+	Scale(f float32)
+}
+`, string(ed.Source()))
+}
+
+func TestEditor_SetEmbeddedPointer(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	*Base
+	sync.Mutex `+"`"+`json:"-"`+"`"+`
+	List[int]
+	Name string
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	for typeName, pointer := range map[string]bool{"Base": false, "Mutex": true, "List": true} {
+		changed, err := ed.SetEmbeddedPointer("Example", typeName, pointer)
+		require.NoError(t, err)
+		assert.True(t, changed, typeName)
+	}
+	changed, err := ed.SetEmbeddedPointer("Example", "Name", true)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Example struct {
+	Base
+	*sync.Mutex `+"`"+`json:"-"`+"`"+`
+	*List[int]
+	Name string
+}
+`, string(ed.Source()))
+
+	changed, err = ed.SetEmbeddedPointer("Example", "sync.Mutex", true)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestEditor_RenameField(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Base
+	// Id is the primary key.
+	Id   int64 `+"`json:\"id\"`"+` // generated
+	A, B int
+	Ts   string
+}
+`), 0644))
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	for oldName, newName := range map[string]string{"Id": "ID", "B": "Second"} {
+		changed, err := ed.RenameField("Example", oldName, newName)
+		require.NoError(t, err)
+		assert.True(t, changed, oldName)
+	}
+	changed, err := ed.RenameField("Example", "Missing", "Other")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	_, err = ed.RenameField("Example", "Ts", "A")
+	assert.EqualError(t, err, "field Example.A already exists")
+	_, err = ed.RenameField("Example", "Base", "Parent")
+	assert.EqualError(t, err, "field Example.Base is embedded and cannot be renamed")
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Example struct {
+	Base
+	// Id is the primary key.
+	ID        int64 `+"`json:\"id\"`"+` // generated
+	A, Second int
+	Ts        string
+}
+`, string(ed.Source()))
+}
+
+func TestEditor_AddField(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	ID int64 `+"`json:\"id\"`"+` // key
+	// trailing comment
+}
+
+type Empty struct{}
+
+type Inline struct{ A int }
+
+func f() {
+	type local struct {
+		A int
+	}
+}
+`), 0644))
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	for _, name := range []string{"Example", "Empty", "Inline"} {
+		changed, err := ed.AddField(name, "CreatedAt", "time.Time", `json:"created_at"`)
+		require.NoError(t, err)
+		assert.True(t, changed, name)
+	}
+	changed, err := ed.AddField("Example", "ID", "string", "")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	_, err = ed.AddField("Example", "Name", "string", `json:"name`)
+	assert.Error(t, err)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Example struct {
+	ID int64 `+"`json:\"id\"`"+` // key
+	// trailing comment
+	CreatedAt time.Time `+"`json:\"created_at\"`"+`
+}
+
+type Empty struct {
+	CreatedAt time.Time `+"`json:\"created_at\"`"+`
+}
+
+type Inline struct {
+	A         int
+	CreatedAt time.Time `+"`json:\"created_at\"`"+`
+}
+
+func f() {
+	type local struct {
+		A int
+	}
+}
+`, string(ed.Source()))
+}
+
+func TestEditor_RemoveField(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	*Base
+	// ID is the key.
+	ID      int64 `+"`json:\"id\"`"+` // generated
+	A, B, C int
+	Name    string
+}
+
+type Single struct {
+	Only string
+}
+
+type Inline struct{ A int; B string }
+`), 0644))
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	for _, step := range []struct{ structName, field string }{
+		{"Example", "Base"}, {"Example", "ID"}, {"Example", "A"}, {"Example", "C"}, {"Single", "Only"}, {"Inline", "A"},
+	} {
+		changed, err := ed.RemoveField(step.structName, step.field)
+		require.NoError(t, err)
+		assert.True(t, changed, step.field)
+	}
+	changed, err := ed.RemoveField("Example", "Missing")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Example struct {
+	B    int
+	Name string
+}
+
+type Single struct {
+}
+
+type Inline struct{ B string }
+`, string(ed.Source()))
+}
+
+func TestEditor_SetFieldTag(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "types.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	ID   int64  `+"`json:\"id\" db:\"id\"`"+` // key
+	Name string
+	Note string `+"`json:\"note\"`"+`
+	A, B int
+}
+`), 0644))
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	for field, want := range map[string]bool{"ID": true, "Name": true, "Note": false, "Missing": false} {
+		tag := map[string]string{"ID": `json:"-"`, "Name": `json:"name"`, "Note": `json:"note"`, "Missing": `json:"x"`}[field]
+		changed, err := ed.SetFieldTag("Example", field, tag)
+		require.NoError(t, err)
+		assert.Equal(t, want, changed, field)
+	}
+	_, err = ed.SetFieldTag("Example", "A", `json:"a"`)
+	assert.ErrorContains(t, err, "field Example.A is declared together with other fields")
+	_, err = ed.SetFieldTag("Example", "Name", `json:"name`)
+	assert.Error(t, err)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+type Example struct {
+	ID   int64  `+"`json:\"-\"`"+` // key
+	Name string `+"`json:\"name\"`"+`
+	Note string `+"`json:\"note\"`"+`
+	A, B int
+}
+`, string(ed.Source()))
+}
@@ -3,7 +3,12 @@ package editor
 import (
 	"fmt"
 	"go/ast"
+	"go/parser"
 	"go/token"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -11,32 +16,42 @@ type importManager struct {
 	file     *ast.File
 	fset     *token.FileSet
 	existing map[string]string
+	added    []importSpec
+	indent   string
 }
 
 func newImportManager(file *ast.File, fset *token.FileSet, src []byte) *importManager {
 	existing := make(map[string]string)
 	for _, imp := range file.Imports {
-		path := strings.Trim(imp.Path.Value, `"`)
-		var name string
-		if imp.Name != nil {
-			name = imp.Name.Name
-		} else {
-			parts := strings.Split(path, "/")
-			name = parts[len(parts)-1]
+		// Dot and blank imports do not bind a name a selector could refer to.
+		if name := localName(imp); name != "." && name != "_" {
+			existing[name] = strings.Trim(imp.Path.Value, `"`)
 		}
-		existing[name] = path
 	}
 	return &importManager{
 		file:     file,
 		fset:     fset,
 		existing: existing,
+		indent:   "\t",
+	}
+}
+
+// localName returns the name an import is referred to by in the file. Without
+// an explicit name it is assumed to be the last element of the path.
+func localName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
 	}
+	path := strings.Trim(imp.Path.Value, `"`)
+	return path[strings.LastIndex(path, "/")+1:]
 }
 
-func (im *importManager) add(required map[string]string, src *[]byte) error {
+func (im *importManager) add(required map[string]string, splice func(start, end int, text string)) error {
 	var toAdd []importSpec
 
-	for alias, pkgPath := range required {
+	// Sorted, so the same edit always produces the same import block.
+	for _, alias := range slices.Sorted(maps.Keys(required)) {
+		pkgPath := required[alias]
 		if _, exists := im.existing[alias]; !exists {
 			toAdd = append(toAdd, importSpec{alias: alias, path: pkgPath})
 			im.existing[alias] = pkgPath
@@ -46,90 +61,239 @@ func (im *importManager) add(required map[string]string, src *[]byte) error {
 	if len(toAdd) == 0 {
 		return nil
 	}
+	im.added = append(im.added, toAdd...)
 
 	if len(im.file.Imports) == 0 {
-		return im.insertNewImportBlock(toAdd, src)
+		return im.insertNewImportBlock(toAdd, splice)
 	}
 
 	importDecl := im.findImportDecl()
 	if importDecl != nil && importDecl.Lparen.IsValid() {
-		return im.addToBlock(importDecl, toAdd, src)
+		return im.addToBlock(importDecl, toAdd, splice)
 	}
 
-	return im.convertToBlock(toAdd, src)
+	return im.convertToBlock(toAdd, splice)
 }
 
-func (im *importManager) insertNewImportBlock(toAdd []importSpec, src *[]byte) error {
-	insertPos := im.findInsertPosition()
-	start := im.fset.Position(insertPos).Offset
+// insertNewImportBlock adds an import declaration right after the package
+// clause, so the rest of the file, including doc comments of the first
+// declaration and the file's trailing newlines, is left as it was.
+func (im *importManager) insertNewImportBlock(toAdd []importSpec, splice func(start, end int, text string)) error {
+	start := im.fset.Position(im.packageClauseEnd()).Offset
+	splice(start, start, "\n\nimport (\n"+im.groupedSpecs(toAdd)+")")
+	return nil
+}
 
-	var lines []string
-	lines = append(lines, "import (")
+// packageClauseEnd returns the end of the package clause, including a comment
+// on the same line.
+func (im *importManager) packageClauseEnd() token.Pos {
+	end := im.file.Name.End()
+	line := im.fset.Position(end).Line
+	for _, cg := range im.file.Comments {
+		if cg.Pos() >= end && im.fset.Position(cg.Pos()).Line == line {
+			end = cg.End()
+		}
+	}
+	return end
+}
+
+// groupedSpecs returns one indented line per spec, standard library imports
+// first and the others after a blank line, each group sorted by path as gofmt
+// does.
+func (im *importManager) groupedSpecs(specs []importSpec) string {
+	var std, other []importSpec
+	for _, spec := range specs {
+		if isStdPath(spec.path) {
+			std = append(std, spec)
+		} else {
+			other = append(other, spec)
+		}
+	}
+	var groups []string
+	for _, group := range [][]importSpec{std, other} {
+		if len(group) > 0 {
+			groups = append(groups, im.specLines(group))
+		}
+	}
+	return strings.Join(groups, "\n")
+}
+
+// specLines returns one indented line per spec, sorted by path.
+func (im *importManager) specLines(specs []importSpec) string {
+	specs = slices.SortedFunc(slices.Values(specs), func(a, b importSpec) int { return strings.Compare(a.path, b.path) })
+	var sb strings.Builder
+	for _, spec := range specs {
+		sb.WriteString(im.indent + spec.String() + "\n")
+	}
+	return sb.String()
+}
+
+// isStdPath reports whether an import path looks like a standard library
+// package: its first element has no dot, unlike a module path.
+func isStdPath(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
+
+// addToBlock inserts the specs into an existing import block without
+// touching the specs, comments and blank lines already there. Each spec goes
+// into the first group of its kind (standard library or not) at its sorted
+// position; a kind without a group gets a new one, standard library first.
+func (im *importManager) addToBlock(importDecl *ast.GenDecl, toAdd []importSpec, splice func(start, end int, text string)) error {
+	groups := im.importGroups(importDecl)
+	if len(groups) == 0 {
+		return im.rewriteBlock(importDecl, toAdd, splice)
+	}
+	tf := im.fset.File(importDecl.Pos())
+	lineOf := func(pos token.Pos) int { return tf.Line(pos) }
+	if lineOf(importDecl.Lparen) == lineOf(groups[0][0].Pos()) || lineOf(importDecl.Rparen) == lineOf(groups[len(groups)-1][len(groups[len(groups)-1])-1].End()) {
+		return im.rewriteBlock(importDecl, toAdd, splice)
+	}
+	// lineStart is the offset of the first line of a spec, including its doc
+	// comment; lineEnd is the offset of the newline ending its last line.
+	lineStart := func(spec *ast.ImportSpec) int {
+		pos := spec.Pos()
+		if spec.Doc != nil {
+			pos = spec.Doc.Pos()
+		}
+		return tf.Offset(tf.LineStart(lineOf(pos)))
+	}
+	lineEnd := func(spec *ast.ImportSpec) int {
+		end := spec.End()
+		if spec.Comment != nil {
+			end = spec.Comment.End()
+		}
+		return tf.Offset(tf.LineStart(lineOf(end)+1)) - 1
+	}
+
+	// Specs before the same existing spec, or after the end of a group, are
+	// inserted together.
+	before := make(map[int][]importSpec)
+	after := make(map[int][]importSpec)
+	var newStd, newOther []importSpec
 	for _, spec := range toAdd {
-		lines = append(lines, fmt.Sprintf("\t\"%s\"", spec.path))
+		std := isStdPath(spec.path)
+		i := slices.IndexFunc(groups, func(group []*ast.ImportSpec) bool { return isStdGroup(group) == std })
+		if i < 0 {
+			if std {
+				newStd = append(newStd, spec)
+			} else {
+				newOther = append(newOther, spec)
+			}
+			continue
+		}
+		group := groups[i]
+		j := slices.IndexFunc(group, func(imp *ast.ImportSpec) bool { return importPath(imp) > spec.path })
+		if j < 0 {
+			end := lineEnd(group[len(group)-1])
+			after[end] = append(after[end], spec)
+		} else {
+			start := lineStart(group[j])
+			before[start] = append(before[start], spec)
+		}
 	}
-	lines = append(lines, ")\n\n")
 
-	newBlock := strings.Join(lines, "\n")
-	*src = append((*src)[:start], append([]byte(newBlock), (*src)[start:]...)...)
+	// Texts are collected in source order and joined when they share an
+	// offset: a new standard library group goes before the specs inserted
+	// ahead of the first existing spec, and a new group of other imports
+	// after the specs appended to the last group.
+	inserts := make(map[int]string)
+	if len(newStd) > 0 {
+		inserts[lineStart(groups[0][0])] += im.specLines(newStd) + "\n"
+	}
+	for offset, specs := range before {
+		inserts[offset] += im.specLines(specs)
+	}
+	for offset, specs := range after {
+		inserts[offset] += "\n" + strings.TrimSuffix(im.specLines(specs), "\n")
+	}
+	if len(newOther) > 0 {
+		last := groups[len(groups)-1]
+		inserts[lineEnd(last[len(last)-1])] += "\n\n" + strings.TrimSuffix(im.specLines(newOther), "\n")
+	}
+
+	// Splice from the end, so earlier offsets stay valid.
+	for _, offset := range slices.Backward(slices.Sorted(maps.Keys(inserts))) {
+		splice(offset, offset, inserts[offset])
+	}
 	return nil
 }
 
-func (im *importManager) findInsertPosition() token.Pos {
-	for _, decl := range im.file.Decls {
-		switch d := decl.(type) {
-		case *ast.GenDecl:
-			if d.Tok == token.IMPORT {
-				continue
-			}
-			return d.Pos()
-		case *ast.FuncDecl:
-			return d.Pos()
+// importGroups splits the specs of an import block into groups separated by
+// blank lines.
+func (im *importManager) importGroups(importDecl *ast.GenDecl) [][]*ast.ImportSpec {
+	var groups [][]*ast.ImportSpec
+	prevLine := 0
+	for _, spec := range importDecl.Specs {
+		imp := spec.(*ast.ImportSpec)
+		first := imp.Pos()
+		if imp.Doc != nil {
+			first = imp.Doc.Pos()
+		}
+		if len(groups) == 0 || im.fset.Position(first).Line > prevLine+1 {
+			groups = append(groups, nil)
 		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], imp)
+		last := imp.End()
+		if imp.Comment != nil {
+			last = imp.Comment.End()
+		}
+		prevLine = im.fset.Position(last).Line
+	}
+	return groups
+}
+
+// isStdGroup reports whether a group of imports holds standard library
+// packages.
+func isStdGroup(group []*ast.ImportSpec) bool {
+	return isStdPath(importPath(group[0]))
+}
+
+func importPath(imp *ast.ImportSpec) string {
+	p, err := strconv.Unquote(imp.Path.Value)
+	if err != nil {
+		return strings.Trim(imp.Path.Value, `"`)
 	}
-	return im.file.FileEnd
+	return p
 }
 
-func (im *importManager) addToBlock(importDecl *ast.GenDecl, toAdd []importSpec, src *[]byte) error {
+// rewriteBlock replaces the specs of an import block that cannot be edited
+// line by line, such as an empty block or one written on a single line.
+func (im *importManager) rewriteBlock(importDecl *ast.GenDecl, toAdd []importSpec, splice func(start, end int, text string)) error {
 	start := im.fset.Position(importDecl.Lparen).Offset
 	end := im.fset.Position(importDecl.Rparen).Offset + 1
 
-	var existingImports []string
+	var lines []string
 	for _, imp := range importDecl.Specs {
-		existingImports = append(existingImports, fmt.Sprintf("\t%s", im.specString(imp)))
+		lines = append(lines, im.indent+im.specString(imp))
 	}
 	for _, spec := range toAdd {
-		existingImports = append(existingImports, fmt.Sprintf("\t\"%s\"", spec.path))
+		lines = append(lines, im.indent+spec.String())
 	}
 
-	newBlock := fmt.Sprintf("(\n%s\n)", strings.Join(existingImports, "\n"))
-	*src = append((*src)[:start], append([]byte(newBlock), (*src)[end:]...)...)
+	splice(start, end, fmt.Sprintf("(\n%s\n)", strings.Join(lines, "\n")))
 	return nil
 }
 
-func (im *importManager) convertToBlock(toAdd []importSpec, src *[]byte) error {
-	for _, decl := range im.file.Decls {
-		gd, ok := decl.(*ast.GenDecl)
-		if !ok || gd.Tok != token.IMPORT {
-			continue
-		}
-
-		start := im.fset.Position(gd.Pos()).Offset
-		end := im.fset.Position(gd.End()).Offset
-
-		var imports []string
-		for _, spec := range gd.Specs {
-			imports = append(imports, fmt.Sprintf("\t%s", im.specString(spec)))
-		}
-		for _, spec := range toAdd {
-			imports = append(imports, fmt.Sprintf("\t\"%s\"", spec.path))
-		}
+// convertToBlock turns a single import such as `import "fmt"` into a block
+// holding it and the added specs, grouped and sorted as gofmt expects.
+func (im *importManager) convertToBlock(toAdd []importSpec, splice func(start, end int, text string)) error {
+	gd := im.findImportDecl()
+	if gd == nil || len(gd.Specs) != 1 {
+		return fmt.Errorf("no import declaration found")
+	}
+	imp := gd.Specs[0].(*ast.ImportSpec)
+	start := im.fset.Position(gd.Pos()).Offset
+	end := im.fset.Position(gd.End()).Offset
 
-		newBlock := fmt.Sprintf("import (\n%s\n)", strings.Join(imports, "\n"))
-		*src = append((*src)[:start], append([]byte(newBlock), (*src)[end:]...)...)
-		return nil
+	var name string
+	if imp.Name != nil {
+		name = imp.Name.Name
 	}
-	return fmt.Errorf("no import declaration found")
+	// The alias keeps an explicit name, e.g. for dot and blank imports.
+	specs := append([]importSpec{{alias: name, path: importPath(imp)}}, toAdd...)
+	splice(start, end, "import (\n"+im.groupedSpecs(specs)+")")
+	return nil
 }
 
 func (im *importManager) findImportDecl() *ast.GenDecl {
@@ -158,3 +322,65 @@ type importSpec struct {
 	alias string
 	path  string
 }
+
+func (s importSpec) String() string {
+	if s.alias == "" || s.alias == path.Base(s.path) {
+		return strconv.Quote(s.path)
+	}
+	return s.alias + " " + strconv.Quote(s.path)
+}
+
+// VerifyImports checks that no two imports share a local name and that every
+// import added by AddImports is used by the file.
+func (e *Editor) VerifyImports() error {
+	file, err := parser.ParseFile(token.NewFileSet(), e.filename(), e.src, parser.SkipObjectResolution)
+	if err != nil {
+		return e.sourceError(err)
+	}
+
+	paths := make(map[string]string)
+	for _, imp := range file.Imports {
+		name := localName(imp)
+		if name == "_" || name == "." {
+			continue
+		}
+		impPath := strings.Trim(imp.Path.Value, `"`)
+		if other, ok := paths[name]; ok {
+			return fmt.Errorf("import %q: name %s is already used by %q", impPath, name, other)
+		}
+		paths[name] = impPath
+	}
+
+	used := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+		}
+		return true
+	})
+	for _, spec := range e.imports.added {
+		if !used[spec.alias] {
+			return fmt.Errorf("import %q is unused", spec.path)
+		}
+	}
+	return nil
+}
+
+// SetIndent sets the indentation of import specs written by AddImports. The
+// default is a tab, as gofmt uses.
+func (e *Editor) SetIndent(indent string) {
+	e.imports.indent = indent
+}
+
+// DetectIndent returns the indentation used by the first indented line of the
+// file, or a tab if there is none.
+func (e *Editor) DetectIndent() string {
+	for _, line := range strings.Split(string(e.src), "\n") {
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed != line && trimmed != "" {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "\t"
+}
@@ -0,0 +1,83 @@
+package editor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tagPair is one key:"value" entry of a struct tag.
+type tagPair struct {
+	key   string
+	value string
+}
+
+// parseTag splits the content of a struct tag into its key:"value" pairs,
+// following the convention documented by reflect.StructTag.
+func parseTag(tag string) ([]tagPair, error) {
+	var pairs []tagPair
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			return pairs, nil
+		}
+		i := strings.IndexByte(tag, ':')
+		if i <= 0 || i+1 >= len(tag) || tag[i+1] != '"' || strings.ContainsAny(tag[:i], " \"") {
+			return nil, fmt.Errorf("malformed struct tag %q", tag)
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		j := 1
+		for j < len(tag) && tag[j] != '"' {
+			if tag[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(tag) {
+			return nil, fmt.Errorf("malformed struct tag value for %s", key)
+		}
+		value, err := strconv.Unquote(tag[:j+1])
+		if err != nil {
+			return nil, fmt.Errorf("struct tag value for %s: %w", key, err)
+		}
+		pairs = append(pairs, tagPair{key: key, value: value})
+		tag = tag[j+1:]
+	}
+}
+
+func formatTag(pairs []tagPair) string {
+	parts := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		parts = append(parts, p.key+":"+strconv.Quote(p.value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// mergeTag sets the keys of add in the tag, replacing the values of keys that
+// are already present and appending the others in order.
+func mergeTag(pairs, add []tagPair) []tagPair {
+	merged := append([]tagPair(nil), pairs...)
+	for _, p := range add {
+		i := 0
+		for i < len(merged) && merged[i].key != p.key {
+			i++
+		}
+		if i < len(merged) {
+			merged[i].value = p.value
+		} else {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// tagLiteral returns the Go literal for a tag's content, preferring a raw
+// string as gofmt'd code does.
+func tagLiteral(content string) string {
+	if strings.Contains(content, "`") {
+		return strconv.Quote(content)
+	}
+	return "`" + content + "`"
+}
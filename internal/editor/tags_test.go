@@ -0,0 +1,34 @@
+package editor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTag(t *testing.T) {
+	pairs, err := parseTag(`json:"name,omitempty"  db:"a \"quoted\" name"`)
+	require.NoError(t, err)
+	assert.Equal(t, []tagPair{{key: "json", value: "name,omitempty"}, {key: "db", value: `a "quoted" name`}}, pairs)
+	assert.Equal(t, `json:"name,omitempty" db:"a \"quoted\" name"`, formatTag(pairs))
+
+	pairs, err = parseTag("")
+	require.NoError(t, err)
+	assert.Empty(t, pairs)
+
+	for _, tag := range []string{`json`, `json:name`, `json:"name`, `:"x"`} {
+		_, err := parseTag(tag)
+		assert.Error(t, err, tag)
+	}
+}
+
+func TestMergeTag(t *testing.T) {
+	merged := mergeTag(
+		[]tagPair{{key: "json", value: "x"}, {key: "validate", value: "omitempty"}},
+		[]tagPair{{key: "validate", value: "required"}, {key: "db", value: "x"}},
+	)
+	assert.Equal(t, []tagPair{{key: "json", value: "x"}, {key: "validate", value: "required"}, {key: "db", value: "x"}}, merged)
+	assert.Equal(t, "`a:\"b\"`", tagLiteral(`a:"b"`))
+	assert.Equal(t, "\"a:\\\"`\\\"\"", tagLiteral("a:\"`\""))
+}
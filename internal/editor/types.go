@@ -0,0 +1,374 @@
+package editor
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// SetTypeDoc inserts or replaces the doc comment of a type declaration. For
+// grouped declarations the comment is attached to the type spec itself.
+func (e *Editor) SetTypeDoc(typeName, text string) (bool, error) {
+	var modified bool
+
+	for _, td := range e.typeDecls(typeName) {
+		doc, pos := td.gen.Doc, td.gen.Pos()
+		if td.gen.Lparen.IsValid() {
+			doc, pos = td.spec.Doc, td.spec.Pos()
+		}
+
+		indent := e.lineIndent(pos)
+		comment := commentLines(text, indent)
+
+		if doc != nil {
+			start := e.offset(doc.Pos())
+			end := e.offset(doc.End())
+			if string(e.src[start:end]) == comment {
+				continue
+			}
+			e.edits = append(e.edits, spanEdit{start: start, end: end, text: comment, owner: typeName})
+		} else {
+			start := e.offset(pos)
+			e.edits = append(e.edits, spanEdit{start: start, end: start, text: comment + "\n" + indent, owner: typeName})
+		}
+		modified = true
+	}
+
+	return modified, nil
+}
+
+// TypeDoc returns the raw text of a type's doc comment, including directive
+// comments such as //editstruct:target that ast.CommentGroup.Text drops.
+func (e *Editor) TypeDoc(typeName string) string {
+	var lines []string
+	for _, td := range e.typeDecls(typeName) {
+		doc := td.gen.Doc
+		if td.gen.Lparen.IsValid() {
+			doc = td.spec.Doc
+		}
+		if doc == nil {
+			continue
+		}
+		for _, c := range doc.List {
+			lines = append(lines, c.Text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenameType renames a type declaration and the references to it in this
+// file. Other files of the package are updated with RenameReferences. It
+// fails if the new name is already declared in the file.
+func (e *Editor) RenameType(oldName, newName string) (bool, error) {
+	if len(e.typeDecls(oldName)) == 0 || oldName == newName {
+		return false, nil
+	}
+	if len(e.typeDecls(newName)) > 0 {
+		return false, fmt.Errorf("type %s is already declared", newName)
+	}
+	return e.RenameReferences(oldName, newName), nil
+}
+
+// RenameReferences renames the references to a type, e.g. in a file of the
+// package that does not declare it. It reports whether there were any.
+func (e *Editor) RenameReferences(oldName, newName string) bool {
+	if oldName == newName {
+		return false
+	}
+	refs := e.typeRefs(oldName)
+	for _, id := range refs {
+		start, end := e.offset(id.Pos()), e.offset(id.End())
+		e.edits = append(e.edits, spanEdit{start: start, end: end, text: newName, owner: oldName})
+	}
+	return len(refs) > 0
+}
+
+// RefersTo reports whether the file declares or refers to a type.
+func (e *Editor) RefersTo(typeName string) bool {
+	return len(e.typeRefs(typeName)) > 0
+}
+
+// typeRefs returns the identifiers naming a package-level type, including its
+// declaration.
+func (e *Editor) typeRefs(typeName string) []*ast.Ident {
+	// Identifiers that name something else: fields, functions, variables,
+	// selectors and labels.
+	other := make(map[*ast.Ident]bool)
+	ast.Inspect(e.file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Field:
+			for _, name := range n.Names {
+				other[name] = true
+			}
+		case *ast.SelectorExpr:
+			other[n.Sel] = true
+		case *ast.FuncDecl:
+			other[n.Name] = true
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				other[name] = true
+			}
+		case *ast.ImportSpec:
+			if n.Name != nil {
+				other[n.Name] = true
+			}
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				for _, lhs := range n.Lhs {
+					if id, ok := lhs.(*ast.Ident); ok {
+						other[id] = true
+					}
+				}
+			}
+		case *ast.KeyValueExpr:
+			if id, ok := n.Key.(*ast.Ident); ok {
+				other[id] = true
+			}
+		case *ast.LabeledStmt:
+			other[n.Label] = true
+		case *ast.BranchStmt:
+			if n.Label != nil {
+				other[n.Label] = true
+			}
+		}
+		return true
+	})
+
+	var refs []*ast.Ident
+	var visit func(n ast.Node) bool
+	visit = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if declaresLocal(n, typeName) {
+				// The name is shadowed, so only the signature refers to the type.
+				if n.Recv != nil {
+					ast.Inspect(n.Recv, visit)
+				}
+				ast.Inspect(n.Type, visit)
+				return false
+			}
+		case *ast.Ident:
+			if n.Name == typeName && !other[n] {
+				refs = append(refs, n)
+			}
+		}
+		return true
+	}
+	ast.Inspect(e.file, visit)
+	return refs
+}
+
+// declaresLocal reports whether a function declares a parameter, result or
+// local variable with the given name.
+func declaresLocal(fn *ast.FuncDecl, name string) bool {
+	var found bool
+	isName := func(expr ast.Expr) bool {
+		id, ok := expr.(*ast.Ident)
+		return ok && id.Name == name
+	}
+	ast.Inspect(fn, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncType:
+			for _, list := range []*ast.FieldList{n.Params, n.Results} {
+				if list == nil {
+					continue
+				}
+				for _, field := range list.List {
+					found = found || slices.ContainsFunc(field.Names, func(id *ast.Ident) bool { return id.Name == name })
+				}
+			}
+		case *ast.AssignStmt:
+			found = found || n.Tok == token.DEFINE && slices.ContainsFunc(n.Lhs, isName)
+		case *ast.RangeStmt:
+			found = found || n.Tok == token.DEFINE && (isName(n.Key) || n.Value != nil && isName(n.Value))
+		case *ast.ValueSpec:
+			found = found || slices.ContainsFunc(n.Names, func(id *ast.Ident) bool { return id.Name == name })
+		}
+		return !found
+	})
+	return found
+}
+
+// ValueNames returns the names of all package-level variables and constants.
+func (e *Editor) ValueNames() []string {
+	var names []string
+	for _, vs := range e.valueSpecs("") {
+		for _, name := range vs.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// valueSpecs returns the package-level var and const specs declaring name, or
+// all of them when name is empty.
+func (e *Editor) valueSpecs(name string) []*ast.ValueSpec {
+	var found []*ast.ValueSpec
+	for _, decl := range e.file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.VAR && gd.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if name == "" || slices.ContainsFunc(vs.Names, func(id *ast.Ident) bool { return id.Name == name }) {
+				found = append(found, vs)
+			}
+		}
+	}
+	return found
+}
+
+// SetValueType rewrites the explicit type of a package-level var or const,
+// e.g. `var MaxSize int32` to `var MaxSize int64`. Names declared together
+// share the type, so all of them change. It reports false when the name is
+// not declared or has no explicit type.
+func (e *Editor) SetValueType(name, newType string) (bool, error) {
+	var modified bool
+	newType = normalizeType(newType)
+	for _, vs := range e.valueSpecs(name) {
+		if vs.Type == nil || e.typeString(vs.Type) == newType {
+			continue
+		}
+		start, end := e.offset(vs.Type.Pos()), e.offset(vs.Type.End())
+		e.edits = append(e.edits, spanEdit{start: start, end: end, text: newType, owner: name})
+		modified = true
+	}
+	return modified, nil
+}
+
+// AliasTarget returns the right-hand side of an alias declaration such as
+// `type Order = orderImpl`. It reports false if typeName is not an alias.
+func (e *Editor) AliasTarget(typeName string) (string, bool) {
+	for _, td := range e.typeDecls(typeName) {
+		if td.spec.Assign.IsValid() {
+			return e.typeString(td.spec.Type), true
+		}
+	}
+	return "", false
+}
+
+// TypeParams returns the names of the type parameters of a generic type.
+func (e *Editor) TypeParams(typeName string) []string {
+	var names []string
+	for _, td := range e.typeDecls(typeName) {
+		if td.spec.TypeParams == nil {
+			continue
+		}
+		for _, field := range td.spec.TypeParams.List {
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+		}
+	}
+	return names
+}
+
+// lineIndent returns the leading whitespace of the line containing pos.
+func (e *Editor) lineIndent(pos token.Pos) string {
+	offset := e.offset(pos)
+	lineStart := bytes.LastIndexByte(e.src[:offset], '\n') + 1
+	line := e.src[lineStart:offset]
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
+
+func commentLines(text, indent string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = "//"
+		} else {
+			lines[i] = "// " + line
+		}
+	}
+	return strings.Join(lines, "\n"+indent)
+}
+
+func (e *Editor) interfaceTypes(typeName string) []*ast.InterfaceType {
+	var found []*ast.InterfaceType
+	for _, td := range e.typeDecls(typeName) {
+		if it, ok := td.spec.Type.(*ast.InterfaceType); ok {
+			found = append(found, it)
+		}
+	}
+	return found
+}
+
+// EditInterface rewrites the signatures of the given interface methods, adding
+// the ones that are missing. Signatures are written without the func keyword,
+// e.g. "(ctx context.Context) error".
+func (e *Editor) EditInterface(typeName string, methods map[string]string) (bool, error) {
+	names := sortedKeys(methods)
+	for _, name := range names {
+		sig := methods[name]
+		expr, err := parser.ParseExpr("func" + sig)
+		if _, ok := expr.(*ast.FuncType); err != nil || !ok {
+			return false, fmt.Errorf("invalid signature for method %s: %q", name, sig)
+		}
+	}
+
+	var modified bool
+	for _, it := range e.interfaceTypes(typeName) {
+		existing := make(map[string]*ast.Field)
+		for _, field := range it.Methods.List {
+			if len(field.Names) == 1 {
+				existing[field.Names[0].Name] = field
+			}
+		}
+
+		var missing []string
+		for _, name := range names {
+			field, ok := existing[name]
+			if !ok {
+				missing = append(missing, name+methods[name])
+				continue
+			}
+			start := e.offset(field.Type.Pos())
+			end := e.offset(field.Type.End())
+			if string(e.src[start:end]) == methods[name] {
+				continue
+			}
+			e.edits = append(e.edits, spanEdit{start: start, end: end, text: methods[name], owner: typeName + "." + name})
+			modified = true
+		}
+
+		if len(missing) == 0 {
+			continue
+		}
+		closing := e.offset(it.Methods.Closing)
+		indent := e.lineIndent(it.Methods.Closing)
+		lineStart := closing - len(indent)
+		if lineStart > 0 && e.src[lineStart-1] == '\n' {
+			var sb strings.Builder
+			for _, method := range missing {
+				sb.WriteString(indent + "\t" + method + "\n")
+			}
+			e.edits = append(e.edits, spanEdit{start: lineStart, end: lineStart, text: sb.String(), owner: typeName})
+		} else if len(it.Methods.List) > 0 {
+			indent = e.lineIndent(it.Pos())
+			var sb strings.Builder
+			for _, method := range missing {
+				sb.WriteString("\n" + indent + "\t" + method)
+			}
+			sb.WriteString("\n" + indent)
+			e.edits = append(e.edits, spanEdit{start: closing, end: closing, text: sb.String(), owner: typeName})
+		} else {
+			indent = e.lineIndent(it.Pos())
+			start := e.offset(it.Interface) + len("interface")
+			var sb strings.Builder
+			sb.WriteString(" {")
+			for _, method := range missing {
+				sb.WriteString("\n" + indent + "\t" + method)
+			}
+			sb.WriteString("\n" + indent + "}")
+			e.edits = append(e.edits, spanEdit{start: start, end: closing + 1, text: sb.String(), owner: typeName})
+		}
+		modified = true
+	}
+
+	return modified, nil
+}
@@ -0,0 +1,414 @@
+package editor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditor_SetTypeDoc(t *testing.T) {
+	t.Run("insert above single declaration", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type First struct {
+	ID int64
+}
+
+type Example struct {
+	ID int64
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetTypeDoc("Example", "Example is an example.\n\nIt has an ID.")
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+
+		assert.Equal(t, `package test
+
+type First struct {
+	ID int64
+}
+
+// Example is an example.
+//
+// It has an ID.
+type Example struct {
+	ID int64
+}
+`, string(ed.Source()))
+	})
+
+	t.Run("replace in grouped declaration", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+// Types group.
+type (
+	// First is old.
+	First struct {
+		ID int64
+	}
+
+	Example struct {
+		ID int64
+	}
+)
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetTypeDoc("First", "First is new.")
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		modified, err = ed.SetTypeDoc("Example", "Example is added.")
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+
+		assert.Equal(t, `package test
+
+// Types group.
+type (
+	// First is new.
+	First struct {
+		ID int64
+	}
+
+	// Example is added.
+	Example struct {
+		ID int64
+	}
+)
+`, string(ed.Source()))
+	})
+
+	t.Run("same doc no change", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+// Example is an example.
+type Example struct {
+	ID int64
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.SetTypeDoc("Example", "Example is an example.")
+		require.NoError(t, err)
+		assert.False(t, modified)
+	})
+}
+
+func TestEditor_EditInterface(t *testing.T) {
+	t.Run("rewrite and add methods", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Store interface {
+	// Get returns an item.
+	Get(id string) (Item, error) // lookup
+	Close() error
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.EditInterface("Store", map[string]string{
+			"Get":    "(ctx context.Context, id string) (Item, error)",
+			"Close":  "() error",
+			"Delete": "(ctx context.Context, id string) error",
+		})
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+
+		assert.Equal(t, `package test
+
+type Store interface {
+	// Get returns an item.
+	Get(ctx context.Context, id string) (Item, error) // lookup
+	Close() error
+	Delete(ctx context.Context, id string) error
+}
+`, string(ed.Source()))
+	})
+
+	t.Run("add to empty interface", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Closer interface{}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.EditInterface("Closer", map[string]string{"Close": "() error"})
+		require.NoError(t, err)
+		assert.True(t, modified)
+
+		ed.Apply()
+
+		assert.Equal(t, `package test
+
+type Closer interface {
+	Close() error
+}
+`, string(ed.Source()))
+	})
+
+	t.Run("unchanged signature", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Closer interface {
+	Close() error
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		modified, err := ed.EditInterface("Closer", map[string]string{"Close": "() error"})
+		require.NoError(t, err)
+		assert.False(t, modified)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte("package test\n\ntype Closer interface{}\n"), 0644)
+		require.NoError(t, err)
+
+		ed, err := ParseFile(filePath)
+		require.NoError(t, err)
+
+		_, err = ed.EditInterface("Closer", map[string]string{"Close": "error"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid signature")
+	})
+}
+
+func TestEditor_TypeDoc(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	original := `package test
+
+// Example is generated.
+//
+//editstruct:target
+type Example struct {
+	ID int64
+}
+
+// Group doc.
+type (
+	// First doc.
+	First struct{}
+
+	Second struct{}
+)
+`
+	err := os.WriteFile(filePath, []byte(original), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "// Example is generated.\n//\n//editstruct:target", ed.TypeDoc("Example"))
+	assert.Equal(t, "// First doc.", ed.TypeDoc("First"))
+	assert.Empty(t, ed.TypeDoc("Second"))
+	assert.Empty(t, ed.TypeDoc("Missing"))
+}
+
+func TestEditor_AliasTarget(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+type Order = internal.Order
+
+type Local = order
+
+type order struct{}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	target, ok := ed.AliasTarget("Order")
+	assert.True(t, ok)
+	assert.Equal(t, "internal.Order", target)
+
+	target, ok = ed.AliasTarget("Local")
+	assert.True(t, ok)
+	assert.Equal(t, "order", target)
+
+	_, ok = ed.AliasTarget("order")
+	assert.False(t, ok)
+	_, ok = ed.AliasTarget("Missing")
+	assert.False(t, ok)
+}
+
+func TestEditor_SetValueType(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+var MaxSize int32 = 10
+
+var (
+	Limit   int32
+	Timeout = 5
+)
+
+const Version uint8 = 1
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"MaxSize", "Limit", "Timeout", "Version"}, ed.ValueNames())
+
+	for name, want := range map[string]bool{"MaxSize": true, "Limit": true, "Version": true, "Timeout": false, "Missing": false} {
+		changed, err := ed.SetValueType(name, "int64")
+		require.NoError(t, err)
+		assert.Equal(t, want, changed, name)
+	}
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+var MaxSize int64 = 10
+
+var (
+	Limit   int64
+	Timeout = 5
+)
+
+const Version int64 = 1
+`, string(ed.Source()))
+}
+
+func TestEditor_RenameType(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	err := os.WriteFile(filePath, []byte(`package test
+
+// Old is renamed.
+type Old struct {
+	Old  string
+	Next *Old
+}
+
+type List []Old
+
+func (o *Old) Copy() Old {
+	Old := *o
+	return Old
+}
+
+func NewOld() *Old {
+	return &Old{Old: "x"}
+}
+`), 0644)
+	require.NoError(t, err)
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+
+	changed, err := ed.RenameType("Missing", "Other")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	_, err = ed.RenameType("Old", "List")
+	assert.EqualError(t, err, "type List is already declared")
+
+	changed, err = ed.RenameType("Old", "New")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+// Old is renamed.
+type New struct {
+	Old  string
+	Next *New
+}
+
+type List []New
+
+func (o *New) Copy() New {
+	Old := *o
+	return Old
+}
+
+func NewOld() *New {
+	return &New{Old: "x"}
+}
+`, string(ed.Source()))
+}
+
+func TestEditor_RenameReferences(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "use.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+var Old = 1
+
+func use(o Old) []Old {
+	return []Old{o}
+}
+`), 0644))
+
+	ed, err := ParseFile(filePath)
+	require.NoError(t, err)
+	assert.True(t, ed.RefersTo("Old"))
+	assert.False(t, ed.RefersTo("Missing"))
+	assert.False(t, ed.RenameReferences("Missing", "Other"))
+	assert.True(t, ed.RenameReferences("Old", "New"))
+	require.NoError(t, ed.Apply())
+
+	assert.Equal(t, `package test
+
+var Old = 1
+
+func use(o New) []New {
+	return []New{o}
+}
+`, string(ed.Source()))
+}
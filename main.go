@@ -4,18 +4,225 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/reddec/editstruct/internal/config"
 	"github.com/reddec/editstruct/internal/editor"
 )
 
+type options struct {
+	failOnUnknownPackage bool
+	quiet                bool
+	skipConstrained      bool
+	dryRun               bool
+	marker               string
+	transactional        bool
+	verify               bool
+	strict               bool
+	maxChanges           int
+	noImports            bool
+	duplicates           editor.DuplicateMode
+	gofmt                bool
+	followAliases        bool
+	diff                 editor.DiffOptions
+	verifyImports        bool
+	onlyChanged          bool
+	werror               bool
+	suggest              bool
+	indent               string
+	requireGofmt         bool
+	verifyBuild          bool
+	localTypes           map[string]bool
+	auditImports         bool
+	explain              io.Writer
+	exec                 string
+	log                  *slog.Logger
+	base                 string
+	// typeRenames holds the renameType directives by package directory, so
+	// files that only refer to a renamed type are updated as well.
+	typeRenames map[string]map[string]string
+}
+
+type fileResult struct {
+	path     string
+	ed       *editor.Editor
+	modified bool
+	edits    []editor.FieldEdit
+	changes  int
+	imports  map[string]string
+	diff     string
+	warnings []string
+	guessed  []guessedImport
+}
+
+// guessedImport is an import whose path was guessed from the package selector
+// of a type the config wrote, e.g. for a field, a method or a var.
+type guessedImport struct {
+	alias   string
+	subject string
+}
+
+type summary struct {
+	dryRun  bool
+	files   int
+	changes int
+	fields  int
+	imports int
+}
+
+func (s *summary) add(res fileResult) {
+	if !res.modified {
+		return
+	}
+	s.files++
+	s.changes += res.changes
+	s.fields += len(res.edits)
+	s.imports += len(res.imports)
+}
+
+// String counts every change made to the files, not only field type edits,
+// so a run that only adds fields or sets tags is reported too.
+func (s summary) String() string {
+	if s.dryRun {
+		return fmt.Sprintf("editstruct: %d files would change, %d changes would be made, %d imports would be added", s.files, s.changes, s.imports)
+	}
+	return fmt.Sprintf("editstruct: %d files changed, %d changes made, %d imports added", s.files, s.changes, s.imports)
+}
+
+// checkLimit reports an error when more than max fields were edited. A zero
+// max disables the check.
+func (s summary) checkLimit(max int) error {
+	if max > 0 && s.fields > max {
+		return fmt.Errorf("editstruct: %d field edits exceed -max-changes %d, nothing written", s.fields, max)
+	}
+	return nil
+}
+
 func main() {
+	opts := options{diff: editor.DefaultDiffOptions}
 	configPath := flag.String("config", "edit.yaml", "path to configuration file")
+	flag.BoolVar(&opts.failOnUnknownPackage, "fail-on-unknown-package", false, "fail when a qualified type's import path cannot be resolved")
+	flag.BoolVar(&opts.quiet, "quiet", false, "do not print the summary line")
+	flag.BoolVar(&opts.skipConstrained, "skip-constrained", false, "skip files whose name or build constraints exclude the current GOOS/GOARCH")
+	flag.BoolVar(&opts.dryRun, "dry-run", false, "print a diff and the imports that would be added instead of writing files")
+	flag.StringVar(&opts.marker, "marker", "", "only edit types whose doc comment contains this marker (e.g. //editstruct:target)")
+	flag.BoolVar(&opts.transactional, "transactional", false, "write files only after every file was edited successfully, rolling back on write failure")
+	flag.BoolVar(&opts.verify, "verify", false, "re-parse edited files and fail before writing if they are not valid Go")
+	flag.BoolVar(&opts.verifyBuild, "verify-build", false, "type-check the whole package with the edits applied and fail before writing on errors")
+	flag.BoolVar(&opts.verifyImports, "verify-imports", false, "fail before writing if imports share a local name or an added import is unused")
+	flag.BoolVar(&opts.onlyChanged, "only-changed", false, "only process files that differ from -base according to git")
+	flag.StringVar(&opts.base, "base", "HEAD", "git revision that -only-changed compares against")
+	flag.BoolVar(&opts.werror, "Werror", false, "treat warnings (ignored config documents, missing fields, guessed import paths) as errors")
+	flag.BoolVar(&opts.suggest, "suggest", false, "suggest similarly named fields for configured fields that do not exist")
+	flag.StringVar(&opts.indent, "indent", "tab", "indentation of added imports: tab, auto (match the file) or a number of spaces")
+	flag.BoolVar(&opts.strict, "strict", false, "fail when a configured field does not exist in its struct")
+	flag.IntVar(&opts.maxChanges, "max-changes", 0, "abort before writing if more than N fields would be edited in total (0 means no limit)")
+	flag.BoolVar(&opts.noImports, "no-imports", false, "do not add imports for qualified types (leave them to goimports)")
+	flag.BoolVar(&opts.requireGofmt, "require-gofmt", false, "fail if a processed file is not gofmt-clean before editing")
+	flag.BoolVar(&opts.gofmt, "gofmt", false, "format every modified file with gofmt before writing")
+	flag.BoolVar(&opts.followAliases, "follow-aliases", false, "also edit the type an alias (type A = B) resolves to when it is defined in the scanned files")
+	flag.IntVar(&opts.diff.Context, "diff-context", opts.diff.Context, "number of context lines in -dry-run diffs")
+	diffFormat := flag.String("diff-format", "unified", "presentation of -dry-run diffs: unified or side-by-side")
+	reportFile := flag.String("report-file", "", "also write the change report to this file (JSON if it ends in .json, text otherwise), even with -dry-run")
+	check := flag.Bool("check", false, "list the files that would change and exit 1 if there are any, without writing (implies -dry-run)")
+	printResult := flag.Bool("print-result", false, "print the full resulting source of each modified file instead of writing it (implies -dry-run)")
+	planFormat := flag.String("plan", "", "print the planned operations in the given format (json) instead of writing files")
+	sinceValue := flag.String("since", "", "only process files modified after this RFC 3339 time")
+	sinceFile := flag.String("since-file", "", "only process files modified after the time stored in this file, and store the start time of each successful run in it")
+	logFormat := flag.String("log-format", "", "log every parsed file, matched struct, edited field and added import to stderr: text or json")
+	recursive := flag.Bool("recursive", false, "scan subdirectories too, skipping vendor, testdata and hidden directories")
+	useGOFILE := flag.Bool("gofile", false, "under go generate, only process the file containing the directive (GOFILE)")
+	validateOnly := flag.Bool("validate", false, "check that every configured type, field and package exists without editing anything")
+	flag.StringVar(&opts.exec, "exec", "", "run this shell command for every modified file after writing it, with {file} replaced by the path")
+	flag.BoolVar(&opts.auditImports, "audit-imports", false, "report every import whose path was guessed from its package selector, per file, after processing")
+	explain := flag.Bool("explain", false, "describe on stderr why each field of a configured type does or does not change")
+	dumpConfig := flag.Bool("dump-config", false, "print the resolved config as YAML and exit without editing")
+	duplicates := flag.String("duplicates", "all", "how to edit types declared more than once in a file: all, first or error")
 	flag.Parse()
 
-	cfg, err := config.Load(*configPath)
+	switch *diffFormat {
+	case "unified":
+	case "side-by-side":
+		opts.diff.SideBySide = true
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -diff-format value %q: want unified or side-by-side\n", *diffFormat)
+		os.Exit(2)
+	}
+
+	if *printResult || *check {
+		opts.dryRun = true
+	}
+	if args := flag.Args(); *check && len(args) == 1 && args[0] == "-" {
+		fmt.Fprintln(os.Stderr, "-check cannot be used when reading from stdin")
+		os.Exit(2)
+	}
+
+	var planJSON bool
+	switch *planFormat {
+	case "":
+	case "json":
+		opts.dryRun = true
+		planJSON = true
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -plan value %q: want json\n", *planFormat)
+		os.Exit(2)
+	}
+
+	if *explain {
+		opts.explain = os.Stderr
+	}
+
+	if _, err := indentString(opts.indent, ""); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	start := time.Now()
+	var since time.Time
+	if *sinceValue != "" {
+		t, err := time.Parse(time.RFC3339, *sinceValue)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid -since value %q: want an RFC 3339 time\n", *sinceValue)
+			os.Exit(2)
+		}
+		since = t
+	} else if *sinceFile != "" {
+		t, err := readStamp(*sinceFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "read -since-file: %v\n", err)
+			os.Exit(1)
+		}
+		since = t
+	}
+
+	switch *logFormat {
+	case "":
+	case "text":
+		opts.log = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	case "json":
+		opts.log = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+	default:
+		fmt.Fprintf(os.Stderr, "invalid -log-format value %q: want text or json\n", *logFormat)
+		os.Exit(2)
+	}
+
+	var err error
+	if opts.duplicates, err = parseDuplicates(*duplicates); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	cfg, warnings, err := config.LoadWithWarnings(*configPath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			fmt.Fprintf(os.Stderr, "config file not found: %s\n", *configPath)
@@ -24,27 +231,299 @@ func main() {
 		}
 		os.Exit(1)
 	}
+	for _, w := range warnings {
+		warn(opts, "%s: %s", *configPath, w)
+	}
+
+	if *dumpConfig {
+		if err := config.Write(os.Stdout, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "dump config: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	if len(cfg) == 0 {
+	if len(cfg) == 0 && !planJSON && *reportFile == "" && !*validateOnly {
 		return
 	}
 
-	files, err := findGoFiles()
+	if args := flag.Args(); len(args) == 1 && args[0] == "-" {
+		if err := editStdin(os.Stdin, os.Stdout, cfg, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "process stdin: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	files, err := inputFiles(flag.Args(), *useGOFILE, *recursive)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "find go files: %v\n", err)
 		os.Exit(1)
 	}
 
+	var moved bool
+	if cfg, moved, err = resolveEmbedded(files, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "resolve embedded types: %v\n", err)
+		os.Exit(1)
+	}
+	if moved {
+		// The edits of one document span several files.
+		opts.transactional = true
+	}
+
+	var spans bool
+	if opts.typeRenames, spans, err = packageRenames(files, cfg, opts.marker); err != nil {
+		fmt.Fprintf(os.Stderr, "resolve renamed types: %v\n", err)
+		os.Exit(1)
+	}
+	if spans {
+		opts.transactional = true
+	}
+
+	if *validateOnly {
+		problems, err := validate(files, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "validate: %v\n", err)
+			os.Exit(1)
+		}
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", *configPath, p)
+		}
+		if len(problems) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opts.followAliases {
+		if cfg, err = followAliases(files, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "follow aliases: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if opts.strict {
+		if opts.localTypes, err = packageTypes(files); err != nil {
+			fmt.Fprintf(os.Stderr, "index types: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if opts.onlyChanged {
+		changed, err := changedFiles(opts.base)
+		if err == nil {
+			var filtered []string
+			if filtered, err = filterChanged(files, changed); err == nil {
+				files = filtered
+			}
+		}
+		if err != nil {
+			warn(opts, "-only-changed: %v; processing all files", err)
+		}
+	}
+
+	if !since.IsZero() {
+		if files, err = filterSince(files, *configPath, since); err != nil {
+			fmt.Fprintf(os.Stderr, "filter files: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	total := summary{dryRun: opts.dryRun}
+	report := newPlan()
+	var staged, audit []fileResult
+	var unclean bool
 	for _, file := range files {
-		if err := processFile(file, cfg); err != nil {
+		var res fileResult
+		if opts.transactional || opts.maxChanges > 0 || opts.verifyBuild {
+			res, err = editFile(file, cfg, opts)
+			staged = append(staged, res)
+		} else {
+			res, err = processFile(file, cfg, opts)
+		}
+		if err != nil {
+			opts.logger().Error("process failed", "path", file, "error", err)
 			fmt.Fprintf(os.Stderr, "process %s: %v\n", file, err)
 			os.Exit(1)
 		}
+		for _, w := range res.warnings {
+			warn(opts, "%s: %s", file, w)
+		}
+		switch {
+		case *check:
+			if res.modified {
+				fmt.Println(file)
+				unclean = true
+			}
+		case *printResult:
+			printSource(file, res)
+		case opts.dryRun && !planJSON:
+			printDryRun(file, res)
+		}
+		report.add(res)
+		total.add(res)
+		if opts.auditImports {
+			audit = append(audit, fileResult{path: res.path, guessed: res.guessed})
+		}
+	}
+
+	if *reportFile != "" {
+		if err := writeReport(*reportFile, report, total); err != nil {
+			fmt.Fprintf(os.Stderr, "write report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := total.checkLimit(opts.maxChanges); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if opts.verifyBuild {
+		if err := verifyBuild(staged); err != nil {
+			fmt.Fprintf(os.Stderr, "verify build: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if opts.dryRun {
+		staged = nil
+	}
+	if planJSON {
+		if err := report.write(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "write plan: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := writeAll(staged); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if opts.exec != "" {
+		// Files written one by one have run the command already.
+		if err := execAll(opts.exec, staged); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if *sinceFile != "" && !opts.dryRun {
+		if err := writeStamp(*sinceFile, start); err != nil {
+			fmt.Fprintf(os.Stderr, "write -since-file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if opts.auditImports {
+		writeAudit(os.Stderr, audit)
+	}
+	if !opts.quiet {
+		fmt.Fprintln(os.Stderr, total)
+	}
+	if unclean {
+		os.Exit(1)
+	}
+}
+
+// writeAudit lists the guessed imports of every file, one per line.
+func writeAudit(w io.Writer, results []fileResult) {
+	var n int
+	for _, res := range results {
+		for _, g := range res.guessed {
+			fmt.Fprintf(w, "%s: guessed import %q for %s\n", res.path, g.alias, g.subject)
+			n++
+		}
 	}
+	fmt.Fprintf(w, "editstruct: %d guessed imports\n", n)
+}
+
+// indentString converts an -indent value to the indentation to use; detected
+// is the file's own indentation, used for "auto".
+func indentString(value, detected string) (string, error) {
+	switch value {
+	case "tab":
+		return "\t", nil
+	case "auto":
+		return detected, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return "", fmt.Errorf("invalid -indent value %q: want tab, auto or a positive number of spaces", value)
+	}
+	return strings.Repeat(" ", n), nil
+}
+
+func parseDuplicates(value string) (editor.DuplicateMode, error) {
+	switch value {
+	case "all":
+		return editor.DuplicatesAll, nil
+	case "first":
+		return editor.DuplicatesFirst, nil
+	case "error":
+		return editor.DuplicatesError, nil
+	}
+	return 0, fmt.Errorf("invalid -duplicates value %q: want all, first or error", value)
+}
+
+// warn reports a condition that does not stop the run, unless -Werror is set.
+func warn(opts options, format string, args ...any) {
+	if opts.werror {
+		fmt.Fprintf(os.Stderr, "editstruct: error: "+format+" (-Werror)\n", args...)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "editstruct: warning: "+format+"\n", args...)
+}
+
+func printDryRun(path string, res fileResult) {
+	fmt.Print(res.diff)
+
+	aliases := make([]string, 0, len(res.imports))
+	for alias := range res.imports {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		fmt.Printf("%s: would add import %s %q\n", path, alias, res.imports[alias])
+	}
+}
+
+func printSource(path string, res fileResult) {
+	if !res.modified {
+		return
+	}
+	fmt.Printf("// file: %s\n", path)
+	os.Stdout.Write(res.ed.Output())
+}
+
+// logger returns the -log-format logger, which discards everything unless the
+// flag is set.
+func (o options) logger() *slog.Logger {
+	if o.log == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return o.log
+}
+
+// inputFiles returns the files to process: the explicit arguments, the file
+// go generate names in GOFILE when useGOFILE is set, or otherwise every Go
+// file in the current directory.
+func inputFiles(args []string, useGOFILE, recursive bool) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	if file := os.Getenv("GOFILE"); useGOFILE && file != "" {
+		return []string{file}, nil
+	}
+	if recursive {
+		return walkGoFiles(".")
+	}
+	return findGoFiles()
 }
 
 func findGoFiles() ([]string, error) {
-	entries, err := os.ReadDir(".")
+	return goFilesIn(".")
+}
+
+// goFilesIn returns the non-test Go files in dir.
+func goFilesIn(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, err
 	}
@@ -56,60 +535,304 @@ func findGoFiles() ([]string, error) {
 		}
 		name := entry.Name()
 		if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
-			files = append(files, name)
+			files = append(files, filepath.Join(dir, name))
 		}
 	}
 	return files, nil
 }
 
-func processFile(path string, configs []config.TypeConfig) error {
-	ed, err := editor.ParseFile(path)
+// walkGoFiles returns the non-test Go files in dir and its subdirectories,
+// skipping vendor, testdata and hidden directories.
+func walkGoFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func processFile(path string, configs []config.TypeConfig, opts options) (fileResult, error) {
+	res, err := editFile(path, configs, opts)
+	if err != nil || opts.dryRun {
+		return res, err
+	}
+	if err := writeResult(res); err != nil {
+		return res, err
+	}
+	if opts.exec != "" && res.modified {
+		return res, runExec(opts.exec, path)
+	}
+	return res, nil
+}
+
+// editFile applies the configuration to a single file in memory.
+func editFile(path string, configs []config.TypeConfig, opts options) (fileResult, error) {
+	src, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return fileResult{path: path}, fmt.Errorf("read file: %w", err)
 	}
+	return editSource(path, src, configs, opts)
+}
+
+// editSource applies the configuration to source read from path, which is
+// only used in messages.
+func editSource(path string, src []byte, configs []config.TypeConfig, opts options) (fileResult, error) {
+	res := fileResult{path: path}
+	log := opts.logger().With("path", path)
+	if !opts.requireGofmt && !mentionsConfig(src, configs) {
+		log.Debug("file skipped", "reason", "no configured name")
+		return res, nil
+	}
+	ed, err := editor.ParseSource(path, src)
+	if err != nil {
+		return res, err
+	}
+	res.ed = ed
+	log.Info("file parsed")
 
-	structNames := ed.StructNames()
-	configMap := make(map[string]config.TypeConfig)
-	for _, c := range configs {
-		configMap[c.Type] = c
+	if opts.requireGofmt {
+		formatted, err := ed.Formatted()
+		if err != nil {
+			return res, err
+		}
+		if !formatted {
+			return res, fmt.Errorf("not gofmt-clean, run gofmt -w %s first (-require-gofmt)", path)
+		}
 	}
+	ed.SetStrict(opts.strict)
+	ed.SetDuplicates(opts.duplicates)
 
-	var anyModified bool
-	for _, name := range structNames {
-		tc, ok := configMap[name]
+	if opts.skipConstrained {
+		ok, err := matchesBuild(path, src)
+		if err != nil {
+			return res, fmt.Errorf("build constraint: %w", err)
+		}
 		if !ok {
+			return res, nil
+		}
+	}
+
+	ed.SetNoImports(opts.noImports)
+	if opts.indent != "" {
+		indent, err := indentString(opts.indent, ed.DetectIndent())
+		if err != nil {
+			return res, err
+		}
+		ed.SetIndent(indent)
+	}
+	importPaths := ed.ImportPaths()
+	renamed, err := renameTypes(ed, configs, opts.marker, opts.typeRenames[filepath.Dir(filepath.Clean(path))])
+	if err != nil {
+		return res, err
+	}
+	for _, tc := range configs {
+		if !tc.When.Matches(importPaths) {
 			continue
 		}
+		if newName, ok := renamed[tc.Type]; ok {
+			tc.Type = newName
+		}
+		if !slices.Contains(ed.StructNames(), tc.Type) || opts.marker != "" && !strings.Contains(ed.TypeDoc(tc.Type), opts.marker) {
+			if len(tc.Vars) == 0 && len(tc.ReplaceTypes) == 0 {
+				continue
+			}
+			tc = config.TypeConfig{Vars: tc.Vars, ReplaceTypes: tc.ReplaceTypes, ImportPaths: tc.ImportPaths}
+		} else {
+			log.Info("struct matched", "struct", tc.Type)
+		}
 
-		modified, err := ed.EditStruct(name, tc.Fields)
+		res.warnings = append(res.warnings, missingFields(ed, tc, opts.suggest)...)
+		if opts.explain != nil {
+			if err := explainFields(opts.explain, path, ed, tc); err != nil {
+				return res, err
+			}
+		}
+		written := len(ed.TypeUses())
+		applied, err := ed.ApplyConfig(tc)
 		if err != nil {
-			return fmt.Errorf("edit struct %s: %w", name, err)
+			return res, err
+		}
+		// Every type the document wrote, not only field edits: map keys,
+		// methods, vars and so on get their imports the same way.
+		for _, use := range ed.TypeUses()[written:] {
+			for _, alias := range tc.UnknownPackages(use.Type) {
+				if opts.failOnUnknownPackage {
+					return res, fmt.Errorf("%s %s: unknown package %q", use.Kind, use.Subject, alias)
+				}
+				res.warnings = append(res.warnings, fmt.Sprintf("%s %s: unknown package %q, importing it as %q", use.Kind, use.Subject, alias, alias))
+				res.guessed = append(res.guessed, guessedImport{alias: alias, subject: use.Subject})
+			}
+		}
+		if opts.localTypes != nil {
+			if err := checkLocalTypes(ed, applied, opts.localTypes); err != nil {
+				return res, err
+			}
 		}
-		if modified {
-			anyModified = true
+		for _, fe := range applied {
+			log.Info("field edited", "struct", fe.Struct, "field", fe.Field, "from", fe.OldType, "to", fe.NewType)
 		}
+		res.edits = append(res.edits, applied...)
+	}
+	if opts.werror && len(res.warnings) > 0 {
+		return res, fmt.Errorf("%s (-Werror)", res.warnings[0])
 	}
 
-	if anyModified {
-		ed.Apply()
+	res.modified = ed.Modified()
+	if !res.modified {
+		// Untouched files are neither verified, formatted nor written.
+		return res, nil
+	}
+	res.changes = ed.Changes()
+	res.imports = ed.AddedImports()
+	for _, alias := range slices.Sorted(maps.Keys(res.imports)) {
+		log.Info("import added", "alias", alias, "import", res.imports[alias])
+	}
 
-		requiredImports := make(map[string]string)
-		for _, tc := range configs {
-			for alias, pkg := range tc.Imports() {
-				requiredImports[alias] = pkg
-			}
+	if opts.verify {
+		if err := ed.VerifySyntax(); err != nil {
+			return res, fmt.Errorf("verify: %w", err)
 		}
+	}
 
-		if len(requiredImports) > 0 {
-			if err := ed.AddImports(requiredImports); err != nil {
-				return fmt.Errorf("add imports: %w", err)
-			}
+	if opts.verifyImports {
+		if err := ed.VerifyImports(); err != nil {
+			return res, fmt.Errorf("verify imports: %w", err)
+		}
+	}
+
+	if opts.gofmt {
+		if err := ed.Format(); err != nil {
+			return res, fmt.Errorf("gofmt %s: %w", path, err)
 		}
 	}
 
-	if anyModified {
-		return ed.WriteTo(path)
+	if opts.dryRun {
+		res.diff = ed.DiffWith(path, opts.diff)
 	}
+	return res, nil
+}
 
+// explainFields writes one line per field of the document's type, saying
+// which key matched it and whether it changes.
+func explainFields(w io.Writer, path string, ed *editor.Editor, tc config.TypeConfig) error {
+	decisions, err := ed.Explain(tc)
+	if err != nil {
+		return err
+	}
+	for _, d := range decisions {
+		if d.Key == "" {
+			fmt.Fprintf(w, "%s: %s.%s: %s: %s\n", path, d.Struct, d.Field, d.OldType, d.Decision)
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s.%s: %s -> %s (key %s): %s\n", path, d.Struct, d.Field, d.OldType, d.NewType, d.Key, d.Decision)
+	}
 	return nil
 }
+
+// renameTypes applies the renameType directives of every document before any
+// other edit, so documents may refer to a type by its old or its new name. It
+// returns the new name of each renamed type.
+func renameTypes(ed *editor.Editor, configs []config.TypeConfig, marker string, inPackage map[string]string) (map[string]string, error) {
+	renamed := make(map[string]string)
+	importPaths := ed.ImportPaths()
+	for _, tc := range configs {
+		if tc.RenameType == "" || !tc.When.Matches(importPaths) || marker != "" && !strings.Contains(ed.TypeDoc(tc.Type), marker) {
+			continue
+		}
+		ok, err := ed.RenameType(tc.Type, tc.RenameType)
+		if err != nil {
+			return nil, fmt.Errorf("rename type %s: %w", tc.Type, err)
+		}
+		if ok {
+			renamed[tc.Type] = tc.RenameType
+		}
+	}
+	// Types declared in another file of the package.
+	for oldName, newName := range inPackage {
+		if !slices.Contains(ed.StructNames(), oldName) {
+			ed.RenameReferences(oldName, newName)
+		}
+	}
+	if err := ed.Apply(); err != nil {
+		return nil, fmt.Errorf("apply: %w", err)
+	}
+	return renamed, nil
+}
+
+// missingFields describes the fields a document refers to that its type lacks,
+// optionally with the closest existing field name.
+func missingFields(ed *editor.Editor, tc config.TypeConfig, withSuggestions bool) []string {
+	var missing []string
+	for _, name := range configuredFields(tc) {
+		if hasConfiguredField(ed, tc, name) {
+			continue
+		}
+		msg := fmt.Sprintf("field %s.%s not found", tc.Type, name)
+		if withSuggestions {
+			if match, ok := suggest(name, ed.FieldNames(tc.Type)); ok {
+				msg += fmt.Sprintf(", did you mean %s?", match)
+			}
+		}
+		missing = append(missing, msg)
+	}
+	return missing
+}
+
+// hasConfiguredField reports whether the document's type has the field, or
+// has it under its new name if the document renames it.
+func hasConfiguredField(ed *editor.Editor, tc config.TypeConfig, name string) bool {
+	if ed.HasField(tc.Type, name) {
+		return true
+	}
+	newName, renamed := tc.Rename[name]
+	return renamed && ed.HasField(tc.Type, newName)
+}
+
+// configuredFields returns the sorted names of the fields a config addresses.
+func configuredFields(tc config.TypeConfig) []string {
+	names := make(map[string]bool)
+	for _, m := range []map[string]string{tc.Fields, tc.LineComments, tc.ChanDir, tc.MapKey, tc.ChanElem, tc.UnwrapPointer} {
+		for name := range m {
+			if !config.IsPattern(name) {
+				names[name] = true
+			}
+		}
+	}
+	for _, m := range []map[string]map[int]string{tc.FuncParams, tc.FuncResults} {
+		for name := range m {
+			names[name] = true
+		}
+	}
+	for name := range tc.Tags {
+		names[name] = true
+	}
+	for _, name := range slices.Concat(tc.ToSlice, tc.FromSlice, tc.DropTag) {
+		names[name] = true
+	}
+	for _, name := range tc.Except {
+		delete(names, name)
+	}
+	// Fields are renamed before anything else, so the other directives may use
+	// a new name that does not exist yet.
+	for oldName, newName := range tc.Rename {
+		delete(names, newName)
+		names[oldName] = true
+	}
+	for name := range tc.Add {
+		delete(names, name)
+	}
+	return slices.Sorted(maps.Keys(names))
+}
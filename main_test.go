@@ -0,0 +1,936 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+	"github.com/reddec/editstruct/internal/editor"
+)
+
+func TestProcessFile(t *testing.T) {
+	t.Run("imports only for applied edits", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Total *int64
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		_, err = processFile(filePath, []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Total": "uint64"}},
+			{Type: "Missing", Fields: map[string]string{"CreatedAt": "time.Time"}},
+		}, options{})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Total uint64")
+		assert.NotContains(t, string(content), `"time"`)
+	})
+
+	t.Run("no import when type is absent", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	ID int64
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		_, err = processFile(filePath, []config.TypeConfig{
+			{Type: "Missing", Fields: map[string]string{"CreatedAt": "time.Time"}},
+		}, options{})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+
+	t.Run("fail on unknown package", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Msg  string
+	Time string
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		cfg := []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Msg": "pb.Message"}},
+		}
+		_, err = processFile(filePath, cfg, options{failOnUnknownPackage: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown package "pb"`)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+
+		cfg = []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Time": "time.Time"}},
+		}
+		_, err = processFile(filePath, cfg, options{failOnUnknownPackage: true})
+		require.NoError(t, err)
+	})
+
+	t.Run("result counts", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Total     *int64
+	CreatedAt string
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Total": "uint64", "CreatedAt": "time.Time"}},
+		}, options{})
+		require.NoError(t, err)
+		assert.Len(t, res.edits, 2)
+		assert.Equal(t, map[string]string{"time": "time"}, res.imports)
+
+		var total summary
+		total.add(res)
+		total.add(fileResult{})
+		assert.Equal(t, "editstruct: 1 files changed, 2 changes made, 1 imports added", total.String())
+
+		res, err = processFile(filePath, []config.TypeConfig{
+			{Type: "Example", Tags: map[string]map[string]string{"Total": {"json": "total"}}, LineComments: map[string]string{"CreatedAt": "set once"}},
+		}, options{})
+		require.NoError(t, err)
+		assert.Empty(t, res.edits)
+
+		total = summary{}
+		total.add(res)
+		assert.Equal(t, "editstruct: 1 files changed, 2 changes made, 0 imports added", total.String())
+	})
+
+	t.Run("multiple structs in one file", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type First struct {
+	Value   int
+	Created string
+}
+
+type Second struct {
+	Data    string
+	Updated string
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{Type: "First", Fields: map[string]string{"Value": "int64", "Created": "time.Time"}},
+			{Type: "Second", Fields: map[string]string{"Data": "[]byte", "Updated": "*time.Time"}},
+		}, options{})
+		require.NoError(t, err)
+		assert.Len(t, res.edits, 4)
+		assert.Equal(t, map[string]string{"time": "time"}, res.imports)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, `package test
+
+import (
+	"time"
+)
+
+type First struct {
+	Value   int64
+	Created time.Time
+}
+
+type Second struct {
+	Data    []byte
+	Updated *time.Time
+}
+`, string(content))
+	})
+
+	t.Run("type doc only", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	ID int64
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{Type: "Example", TypeDoc: "Example is generated."},
+		}, options{})
+		require.NoError(t, err)
+		assert.True(t, res.modified)
+		assert.Empty(t, res.edits)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "// Example is generated.\ntype Example struct")
+	})
+
+	t.Run("skip constrained file", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `//go:build ignore
+
+package test
+
+type Example struct {
+	Total *int64
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		cfg := []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Total": "uint64"}},
+		}
+		res, err := processFile(filePath, cfg, options{skipConstrained: true})
+		require.NoError(t, err)
+		assert.False(t, res.modified)
+
+		res, err = processFile(filePath, cfg, options{})
+		require.NoError(t, err)
+		assert.True(t, res.modified)
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Client *int64
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Client": "*http.Client"}},
+		}, options{dryRun: true})
+		require.NoError(t, err)
+		assert.True(t, res.modified)
+		assert.Equal(t, map[string]string{"http": "net/http"}, res.imports)
+		assert.Contains(t, res.diff, "+\t\"net/http\"\n")
+		assert.Contains(t, res.diff, "-\tClient *int64\n+\tClient *http.Client\n")
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+
+		total := summary{dryRun: true}
+		total.add(res)
+		assert.Equal(t, "editstruct: 1 files would change, 1 changes would be made, 1 imports would be added", total.String())
+	})
+
+	t.Run("marker filter", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+//editstruct:target
+type First struct {
+	Total *int64
+}
+
+type Second struct {
+	Total *int64
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{Type: "First", Fields: map[string]string{"Total": "uint64"}},
+			{Type: "Second", Fields: map[string]string{"Total": "uint64"}},
+		}, options{marker: "//editstruct:target"})
+		require.NoError(t, err)
+		require.Len(t, res.edits, 1)
+		assert.Equal(t, "First", res.edits[0].Struct)
+	})
+
+	t.Run("verify rejects invalid edit", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Total *int64
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+
+		_, err = processFile(filePath, []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Total": "[]"}},
+		}, options{verify: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Example.Total")
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+
+	t.Run("strict rejects missing field", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Total *int64
+}
+`), 0644)
+		require.NoError(t, err)
+
+		_, err = processFile(filePath, []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Totl": "uint64"}},
+		}, options{strict: true})
+		require.ErrorIs(t, err, editor.ErrFieldNotFound)
+	})
+
+	t.Run("no imports", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	CreatedAt string
+}
+`), 0644)
+		require.NoError(t, err)
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"CreatedAt": "time.Time"}},
+		}, options{noImports: true})
+		require.NoError(t, err)
+		assert.Empty(t, res.imports)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "CreatedAt time.Time")
+		assert.NotContains(t, string(content), "import")
+	})
+
+	t.Run("gofmt whole file", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Total *int64
+}
+
+var  untidy   = 1
+`), 0644)
+		require.NoError(t, err)
+
+		_, err = processFile(filePath, []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Total": "uint64"}},
+		}, options{gofmt: true})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "var untidy = 1")
+	})
+
+	t.Run("local named type", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+type OrderStatus int
+
+type Order struct {
+	Status int
+	Items  []string
+}
+`), 0644)
+		require.NoError(t, err)
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{Type: "Order", Fields: map[string]string{"Status": "OrderStatus", "Items": "map[OrderStatus][]*Order"}},
+		}, options{failOnUnknownPackage: true, verify: true})
+		require.NoError(t, err)
+		assert.Len(t, res.edits, 2)
+		assert.Empty(t, res.imports)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Status OrderStatus")
+		assert.NotContains(t, string(content), "import")
+	})
+
+	t.Run("when guard", func(t *testing.T) {
+		dir := t.TempDir()
+		withSQL := filepath.Join(dir, "with.go")
+		err := os.WriteFile(withSQL, []byte(`package test
+
+import "database/sql"
+
+var _ sql.DB
+
+type Row struct {
+	Name string
+}
+`), 0644)
+		require.NoError(t, err)
+		withoutSQL := filepath.Join(dir, "without.go")
+		original := `package test
+
+type Row struct {
+	Name string
+}
+`
+		err = os.WriteFile(withoutSQL, []byte(original), 0644)
+		require.NoError(t, err)
+
+		configs := []config.TypeConfig{{
+			Type:   "Row",
+			Fields: map[string]string{"Name": "sql.NullString"},
+			When:   config.Condition{Imports: []string{"database/sql"}},
+		}}
+
+		res, err := processFile(withSQL, configs, options{})
+		require.NoError(t, err)
+		assert.True(t, res.modified)
+
+		res, err = processFile(withoutSQL, configs, options{})
+		require.NoError(t, err)
+		assert.False(t, res.modified)
+		content, err := os.ReadFile(withoutSQL)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+
+	t.Run("var types", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+var DefaultTimeout int64
+`), 0644)
+		require.NoError(t, err)
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{Vars: map[string]string{"DefaultTimeout": "time.Duration"}},
+		}, options{})
+		require.NoError(t, err)
+		assert.True(t, res.modified)
+		assert.Equal(t, map[string]string{"time": "time"}, res.imports)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "var DefaultTimeout time.Duration")
+	})
+
+	t.Run("imported generic type", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Name      *string
+	UpdatedAt *string
+}
+`), 0644)
+		require.NoError(t, err)
+
+		res, err := processFile(filePath, []config.TypeConfig{{
+			Type:        "Example",
+			Fields:      map[string]string{"Name": "opt.Optional[string]", "UpdatedAt": "opt.Optional[time.Time]"},
+			ImportPaths: map[string]string{"opt": "example.com/lib/opt"},
+		}}, options{verify: true, verifyImports: true})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"opt": "example.com/lib/opt", "time": "time"}, res.imports)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "\tName      opt.Optional[string]\n")
+		assert.Contains(t, string(content), "\tUpdatedAt opt.Optional[time.Time]\n")
+		assert.Contains(t, string(content), "\t\"example.com/lib/opt\"\n")
+
+		res, err = processFile(filePath, []config.TypeConfig{{
+			Type:   "Example",
+			Fields: map[string]string{"Name": "opt.Optional[ string ]"},
+		}}, options{})
+		require.NoError(t, err)
+		assert.False(t, res.modified)
+	})
+
+	t.Run("map key with import", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		err := os.WriteFile(filePath, []byte(`package test
+
+type Example struct {
+	Index map[string]int `+"`"+`json:"index"`+"`"+`
+}
+`), 0644)
+		require.NoError(t, err)
+
+		res, err := processFile(filePath, []config.TypeConfig{{
+			Type:        "Example",
+			MapKey:      map[string]string{"Index": "uuid.UUID"},
+			ImportPaths: map[string]string{"uuid": "github.com/google/uuid"},
+		}}, options{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"uuid": "github.com/google/uuid"}, res.imports)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "\tIndex map[uuid.UUID]int `json:\"index\"`\n")
+	})
+
+	t.Run("warnings and Werror", func(t *testing.T) {
+		dir := t.TempDir()
+		filePath := filepath.Join(dir, "types.go")
+		original := `package test
+
+type Example struct {
+	Total *int64
+	ID    string
+}
+`
+		err := os.WriteFile(filePath, []byte(original), 0644)
+		require.NoError(t, err)
+		configs := []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Total": "uint64", "Totl": "uint64", "ID": "uuid.UUID"}},
+		}
+
+		res, err := editFile(filePath, configs, options{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"field Example.Totl not found",
+			`field Example.ID: unknown package "uuid", importing it as "uuid"`,
+		}, res.warnings)
+
+		res, err = editFile(filePath, configs, options{suggest: true})
+		require.NoError(t, err)
+		assert.Equal(t, "field Example.Totl not found, did you mean Total?", res.warnings[0])
+
+		_, err = processFile(filePath, configs, options{werror: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field Example.Totl not found (-Werror)")
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+
+	t.Run("per-type import paths", func(t *testing.T) {
+		dir := t.TempDir()
+		firstPath := filepath.Join(dir, "first.go")
+		secondPath := filepath.Join(dir, "second.go")
+		require.NoError(t, os.WriteFile(firstPath, []byte("package test\n\ntype First struct {\n\tID string\n}\n"), 0644))
+		require.NoError(t, os.WriteFile(secondPath, []byte("package test\n\ntype Second struct {\n\tID string\n}\n"), 0644))
+
+		cfg := []config.TypeConfig{
+			{Type: "First", Fields: map[string]string{"ID": "pkg.T"}, ImportPaths: map[string]string{"pkg": "example.com/a/pkg"}},
+			{Type: "Second", Fields: map[string]string{"ID": "pkg.T"}, ImportPaths: map[string]string{"pkg": "example.com/b/pkg"}},
+		}
+		for _, path := range []string{firstPath, secondPath} {
+			_, err := processFile(path, cfg, options{failOnUnknownPackage: true})
+			require.NoError(t, err)
+		}
+
+		content, err := os.ReadFile(firstPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"example.com/a/pkg"`)
+
+		content, err = os.ReadFile(secondPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"example.com/b/pkg"`)
+	})
+
+	t.Run("require gofmt", func(t *testing.T) {
+		dir := t.TempDir()
+		tidyPath := filepath.Join(dir, "tidy.go")
+		untidyPath := filepath.Join(dir, "untidy.go")
+		require.NoError(t, os.WriteFile(tidyPath, []byte("package test\n\ntype Example struct {\n\tID int64\n}\n"), 0644))
+		untidy := "package test\n\ntype Example struct {\n  ID int64\n}\n"
+		require.NoError(t, os.WriteFile(untidyPath, []byte(untidy), 0644))
+		cfg := []config.TypeConfig{{Type: "Example", Fields: map[string]string{"ID": "string"}}}
+
+		res, err := processFile(tidyPath, cfg, options{requireGofmt: true})
+		require.NoError(t, err)
+		assert.True(t, res.modified)
+
+		_, err = processFile(untidyPath, cfg, options{requireGofmt: true})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not gofmt-clean, run gofmt -w "+untidyPath)
+
+		content, err := os.ReadFile(untidyPath)
+		require.NoError(t, err)
+		assert.Equal(t, untidy, string(content))
+	})
+
+	t.Run("gofmt skips untouched files", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		untidy := "package test\n\ntype Example struct {\n  ID   int64\n}\n"
+		require.NoError(t, os.WriteFile(filePath, []byte(untidy), 0644))
+		old := time.Now().Add(-time.Hour).Truncate(time.Second)
+		require.NoError(t, os.Chtimes(filePath, old, old))
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"ID": "int64"}},
+		}, options{gofmt: true})
+		require.NoError(t, err)
+		assert.False(t, res.modified)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, untidy, string(content))
+		info, err := os.Stat(filePath)
+		require.NoError(t, err)
+		assert.True(t, info.ModTime().Equal(old))
+	})
+
+	t.Run("global type replacement", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\ntype A struct {\n\tX float32\n}\n\ntype B struct {\n\tY float32\n\tAt string\n}\n"), 0644))
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{ReplaceTypes: map[string]string{"float32": "float64", "string": "uuid.UUID"}, ImportPaths: map[string]string{"uuid": "github.com/google/uuid"}},
+		}, options{})
+		require.NoError(t, err)
+		assert.Len(t, res.edits, 3)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\nimport (\n\t\"github.com/google/uuid\"\n)\n\ntype A struct {\n\tX float64\n}\n\ntype B struct {\n\tY  float64\n\tAt uuid.UUID\n}\n", string(content))
+	})
+
+	t.Run("type rename before field edits", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\ntype Old struct {\n\tID    string\n\tTotal int\n}\n"), 0644))
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{Type: "New", Fields: map[string]string{"ID": "uuid.UUID"}, ImportPaths: map[string]string{"uuid": "github.com/google/uuid"}},
+			{Type: "Old", RenameType: "New", Fields: map[string]string{"Total": "int64"}},
+		}, options{strict: true})
+		require.NoError(t, err)
+		assert.Equal(t, []editor.FieldEdit{
+			{Struct: "New", Field: "ID", OldType: "string", NewType: "uuid.UUID"},
+			{Struct: "New", Field: "Total", OldType: "int", NewType: "int64"},
+		}, res.edits)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\nimport (\n\t\"github.com/google/uuid\"\n)\n\ntype New struct {\n\tID    uuid.UUID\n\tTotal int64\n}\n", string(content))
+	})
+
+	t.Run("type from two packages", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\nimport \"fmt\"\n\ntype Example struct {\n\tSeen  map[string]string\n\tOwner fmt.Stringer\n}\n\ntype Other struct {\n\tID string\n}\n"), 0644))
+
+		cfg := []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Seen": "map[uuid.UUID]time.Time"}, ImportPaths: map[string]string{"uuid": "github.com/google/uuid"}},
+			{Type: "Other", Fields: map[string]string{"ID": "uuid.UUID"}, ImportPaths: map[string]string{"uuid": "github.com/google/uuid"}},
+		}
+		assert.Equal(t, map[string]string{"uuid": "github.com/google/uuid", "time": "time"}, cfg[0].Imports())
+
+		res, err := processFile(filePath, cfg, options{verify: true, verifyImports: true})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"uuid": "github.com/google/uuid", "time": "time"}, res.imports)
+
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, 1, strings.Count(string(content), `"github.com/google/uuid"`))
+		assert.Equal(t, 1, strings.Count(string(content), `"time"`))
+		assert.Equal(t, 1, strings.Count(string(content), `"fmt"`))
+		assert.Contains(t, string(content), "\tSeen  map[uuid.UUID]time.Time\n")
+	})
+
+	t.Run("audit guessed imports", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\ntype Example struct {\n\tID  string\n\tMsg string\n\tAt  string\n}\n"), 0644))
+
+		res, err := processFile(filePath, []config.TypeConfig{{
+			Type:        "Example",
+			Fields:      map[string]string{"ID": "uuid.UUID", "Msg": "pb.Message", "At": "time.Time"},
+			ImportPaths: map[string]string{"uuid": "github.com/google/uuid"},
+		}}, options{auditImports: true})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		writeAudit(&buf, []fileResult{res})
+		assert.Equal(t, filePath+`: guessed import "pb" for Example.Msg`+"\neditstruct: 1 guessed imports\n", buf.String())
+	})
+
+	t.Run("audit guessed imports of every directive", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		src := `package test
+
+var Limit int32
+
+type Example struct {
+	Index map[string]int
+	Done  chan string
+	Call  func(string)
+}
+
+type Store interface {
+	Get() error
+}
+`
+		require.NoError(t, os.WriteFile(filePath, []byte(src), 0644))
+
+		res, err := processFile(filePath, []config.TypeConfig{
+			{
+				Type:       "Example",
+				Add:        map[string]string{"Meta": "meta.Info"},
+				MapKey:     map[string]string{"Index": "key.ID"},
+				ChanElem:   map[string]string{"Done": "ev.Event"},
+				FuncParams: map[string]map[int]string{"Call": {0: "req.Request"}},
+			},
+			{Type: "Store", Methods: map[string]string{"Get": "() (*rec.Record, error)"}},
+			{Vars: map[string]string{"Limit": "units.Size"}},
+		}, options{auditImports: true, noImports: true})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		writeAudit(&buf, []fileResult{res})
+		assert.Equal(t, filePath+`: guessed import "meta" for Example.Meta
+`+filePath+`: guessed import "key" for Example.Index
+`+filePath+`: guessed import "ev" for Example.Done
+`+filePath+`: guessed import "req" for Example.Call
+`+filePath+`: guessed import "rec" for Store.Get
+`+filePath+`: guessed import "units" for Limit
+editstruct: 6 guessed imports
+`, buf.String())
+		assert.Contains(t, res.warnings, `var Limit: unknown package "units", importing it as "units"`)
+		assert.Contains(t, res.warnings, `method Store.Get: unknown package "rec", importing it as "rec"`)
+	})
+
+	t.Run("explain", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		src := "package test\n\ntype Example struct {\n\tTotal *int64\n\tName  string\n}\n"
+		require.NoError(t, os.WriteFile(filePath, []byte(src), 0644))
+
+		var buf bytes.Buffer
+		res, err := editFile(filePath, []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"Total": "uint64"}},
+		}, options{explain: &buf})
+		require.NoError(t, err)
+		assert.Len(t, res.edits, 1)
+		assert.Equal(t, filePath+": Example.Total: *int64 -> uint64 (key Total): changed\n"+
+			filePath+": Example.Name: string: not-configured\n", buf.String())
+	})
+
+	t.Run("rename twice", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\ntype Example struct {\n\tId int\n}\n"), 0644))
+		cfg := []config.TypeConfig{{Type: "Example", Rename: map[string]string{"Id": "ID"}, Fields: map[string]string{"ID": "int64"}}}
+
+		for range 2 {
+			res, err := processFile(filePath, cfg, options{werror: true})
+			require.NoError(t, err)
+			assert.Empty(t, res.warnings)
+		}
+		content, err := os.ReadFile(filePath)
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\ntype Example struct {\n\tID int64\n}\n", string(content))
+	})
+
+	t.Run("json log", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "types.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\ntype Example struct {\n\tCreatedAt string\n}\n"), 0644))
+
+		var buf bytes.Buffer
+		handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if a.Key == slog.TimeKey {
+					return slog.Attr{}
+				}
+				return a
+			},
+		})
+		_, err := editFile(filePath, []config.TypeConfig{
+			{Type: "Example", Fields: map[string]string{"CreatedAt": "time.Time"}},
+		}, options{log: slog.New(handler)})
+		require.NoError(t, err)
+
+		path, _ := json.Marshal(filePath)
+		assert.Equal(t, strings.Join([]string{
+			`{"level":"INFO","msg":"file parsed","path":` + string(path) + `}`,
+			`{"level":"INFO","msg":"struct matched","path":` + string(path) + `,"struct":"Example"}`,
+			`{"level":"INFO","msg":"field edited","path":` + string(path) + `,"struct":"Example","field":"CreatedAt","from":"string","to":"time.Time"}`,
+			`{"level":"INFO","msg":"import added","path":` + string(path) + `,"alias":"time","import":"time"}`,
+		}, "\n")+"\n", buf.String())
+	})
+}
+
+func TestSummary_CheckLimit(t *testing.T) {
+	total := summary{files: 2, fields: 5}
+
+	assert.NoError(t, total.checkLimit(0))
+	assert.NoError(t, total.checkLimit(5))
+
+	err := total.checkLimit(4)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "5 field edits exceed -max-changes 4")
+}
+
+func TestParseDuplicates(t *testing.T) {
+	mode, err := parseDuplicates("first")
+	require.NoError(t, err)
+	assert.Equal(t, editor.DuplicatesFirst, mode)
+
+	_, err = parseDuplicates("some")
+	assert.Error(t, err)
+}
+
+func TestIndentString(t *testing.T) {
+	indent, err := indentString("4", "\t")
+	require.NoError(t, err)
+	assert.Equal(t, "    ", indent)
+
+	indent, err = indentString("auto", "  ")
+	require.NoError(t, err)
+	assert.Equal(t, "  ", indent)
+
+	_, err = indentString("spaces", "\t")
+	assert.Error(t, err)
+}
+
+func TestInputFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, name := range []string{"a.go", "b.go", "b_test.go"} {
+		require.NoError(t, os.WriteFile(name, []byte("package a\n"), 0644))
+	}
+
+	t.Run("scan", func(t *testing.T) {
+		t.Setenv("GOFILE", "b.go")
+		files, err := inputFiles(nil, false, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.go", "b.go"}, files)
+	})
+
+	t.Run("gofile", func(t *testing.T) {
+		t.Setenv("GOFILE", "b.go")
+		files, err := inputFiles(nil, true, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b.go"}, files)
+	})
+
+	t.Run("gofile unset", func(t *testing.T) {
+		t.Setenv("GOFILE", "")
+		files, err := inputFiles(nil, true, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.go", "b.go"}, files)
+	})
+
+	t.Run("arguments", func(t *testing.T) {
+		t.Setenv("GOFILE", "b.go")
+		files, err := inputFiles([]string{"a.go"}, true, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.go"}, files)
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		t.Setenv("GOFILE", "")
+		for _, dir := range []string{"pkg/sub", "vendor/lib", "testdata", ".git", "pkg/.hidden"} {
+			require.NoError(t, os.MkdirAll(dir, 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "c.go"), []byte("package c\n"), 0644))
+		}
+		require.NoError(t, os.WriteFile("pkg/sub/c_test.go", []byte("package c\n"), 0644))
+
+		files, err := inputFiles(nil, false, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.go", "b.go", filepath.Join("pkg", "sub", "c.go")}, files)
+	})
+}
+
+// runMain runs main with the given arguments in dir, in a subprocess so that
+// its exit status can be checked, and returns its stdout and exit code.
+func runMain(t *testing.T, dir string, stdin string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRunMainHelper$")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "EDITSTRUCT_MAIN_ARGS="+strings.Join(args, "\n"))
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	}
+	require.NoError(t, err)
+	return string(out), 0
+}
+
+func TestRunMainHelper(t *testing.T) {
+	args, ok := os.LookupEnv("EDITSTRUCT_MAIN_ARGS")
+	if !ok {
+		t.Skip("only run by runMain")
+	}
+	os.Args = append([]string{"editstruct"}, strings.Split(args, "\n")...)
+	main()
+	os.Exit(0)
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	src := "package test\n\ntype Example struct {\n\tTotal *int64\n}\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "types.go"), []byte(src), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.go"), []byte("package test\n\ntype Other struct{}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "edit.yaml"), []byte("type: Example\nfields:\n  Total: int64\n"), 0644))
+
+	t.Run("lists files that would change", func(t *testing.T) {
+		out, code := runMain(t, dir, "", "-check", "-quiet")
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "types.go\n", out)
+		content, err := os.ReadFile(filepath.Join(dir, "types.go"))
+		require.NoError(t, err)
+		assert.Equal(t, src, string(content))
+	})
+
+	t.Run("nothing to change", func(t *testing.T) {
+		out, code := runMain(t, dir, "", "-check", "-quiet", "other.go")
+		assert.Equal(t, 0, code)
+		assert.Empty(t, out)
+	})
+
+	t.Run("stdin is rejected", func(t *testing.T) {
+		out, code := runMain(t, dir, src, "-check", "-")
+		assert.Equal(t, 2, code)
+		assert.Empty(t, out)
+	})
+}
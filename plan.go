@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// planVersion is bumped whenever the JSON plan schema changes incompatibly.
+const planVersion = 1
+
+type plan struct {
+	Version int        `json:"version"`
+	Files   []planFile `json:"files"`
+}
+
+type planFile struct {
+	Path    string       `json:"path"`
+	Edits   []planEdit   `json:"edits"`
+	Imports []planImport `json:"imports"`
+}
+
+type planEdit struct {
+	Struct string `json:"struct"`
+	Field  string `json:"field"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+type planImport struct {
+	Alias string `json:"alias"`
+	Path  string `json:"path"`
+}
+
+func newPlan() *plan {
+	return &plan{Version: planVersion, Files: []planFile{}}
+}
+
+func (p *plan) add(res fileResult) {
+	if !res.modified {
+		return
+	}
+	file := planFile{Path: res.path, Edits: []planEdit{}, Imports: []planImport{}}
+	for _, fe := range res.edits {
+		file.Edits = append(file.Edits, planEdit{Struct: fe.Struct, Field: fe.Field, From: fe.OldType, To: fe.NewType})
+	}
+	for alias, path := range res.imports {
+		file.Imports = append(file.Imports, planImport{Alias: alias, Path: path})
+	}
+	sort.Slice(file.Imports, func(i, j int) bool {
+		return file.Imports[i].Alias < file.Imports[j].Alias
+	})
+	p.Files = append(p.Files, file)
+}
+
+// writeText writes the plan in a line-oriented form followed by the summary.
+func (p *plan) writeText(w io.Writer, total summary) error {
+	for _, file := range p.Files {
+		for _, e := range file.Edits {
+			fmt.Fprintf(w, "%s: %s.%s: %s -> %s\n", file.Path, e.Struct, e.Field, e.From, e.To)
+		}
+		for _, imp := range file.Imports {
+			fmt.Fprintf(w, "%s: import %s %q\n", file.Path, imp.Alias, imp.Path)
+		}
+	}
+	_, err := fmt.Fprintln(w, total)
+	return err
+}
+
+// writeReport saves the plan to path, as JSON when the name ends in .json.
+func writeReport(path string, p *plan, total summary) error {
+	var buf bytes.Buffer
+	var err error
+	if filepath.Ext(path) == ".json" {
+		err = p.write(&buf)
+	} else {
+		err = p.writeText(&buf, total)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func (p *plan) write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+	"github.com/reddec/editstruct/internal/editor"
+)
+
+func TestPlan(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "types.go")
+	original := `package test
+
+type Example struct {
+	Total     *int64
+	CreatedAt string
+}
+`
+	require.NoError(t, os.WriteFile(filePath, []byte(original), 0644))
+
+	res, err := processFile(filePath, []config.TypeConfig{
+		{Type: "Example", Fields: map[string]string{"Total": "uint64", "CreatedAt": "time.Time"}},
+	}, options{dryRun: true})
+	require.NoError(t, err)
+
+	planned := newPlan()
+	planned.add(res)
+	planned.add(fileResult{path: "untouched.go"})
+
+	var buf bytes.Buffer
+	require.NoError(t, planned.write(&buf))
+	assert.JSONEq(t, `{
+		"version": 1,
+		"files": [{
+			"path": "`+filePath+`",
+			"edits": [
+				{"struct": "Example", "field": "Total", "from": "*int64", "to": "uint64"},
+				{"struct": "Example", "field": "CreatedAt", "from": "string", "to": "time.Time"}
+			],
+			"imports": [{"alias": "time", "path": "time"}]
+		}]
+	}`, buf.String())
+
+	content, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(content))
+}
+
+func TestWriteReport(t *testing.T) {
+	report := newPlan()
+	report.add(fileResult{
+		path:     "types.go",
+		modified: true,
+		edits:    []editor.FieldEdit{{Struct: "Example", Field: "CreatedAt", OldType: "string", NewType: "time.Time"}},
+		imports:  map[string]string{"time": "time"},
+	})
+	total := summary{dryRun: true, files: 1, changes: 1, fields: 1, imports: 1}
+	dir := t.TempDir()
+
+	t.Run("text", func(t *testing.T) {
+		path := filepath.Join(dir, "report.txt")
+		require.NoError(t, writeReport(path, report, total))
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, `types.go: Example.CreatedAt: string -> time.Time
+types.go: import time "time"
+editstruct: 1 files would change, 1 changes would be made, 1 imports would be added
+`, string(content))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		path := filepath.Join(dir, "report.json")
+		require.NoError(t, writeReport(path, report, total))
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `"version": 1`)
+		assert.Contains(t, string(content), `"to": "time.Time"`)
+	})
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/reddec/editstruct/internal/config"
+	"github.com/reddec/editstruct/internal/editor"
+)
+
+// packageRenames finds the renameType directives that apply to a type
+// declared in one of the files and returns them by package directory, so that
+// the other files of the package get their references renamed too. It reports
+// whether another scanned file refers to a renamed type, and fails if the new
+// name is already declared in the package or a file of the package that is
+// not processed refers to the old name.
+func packageRenames(files []string, configs []config.TypeConfig, marker string) (map[string]map[string]string, bool, error) {
+	if !slices.ContainsFunc(configs, func(tc config.TypeConfig) bool { return tc.RenameType != "" }) {
+		return nil, false, nil
+	}
+
+	scanned := make(map[string]*editor.Editor)
+	for _, path := range files {
+		ed, err := editor.ParseFile(path)
+		if err != nil {
+			return nil, false, err
+		}
+		scanned[filepath.Clean(path)] = ed
+	}
+
+	renames := make(map[string]map[string]string)
+	for _, path := range slices.Sorted(maps.Keys(scanned)) {
+		ed := scanned[path]
+		importPaths := ed.ImportPaths()
+		for _, tc := range configs {
+			if tc.RenameType == "" || tc.RenameType == tc.Type || !slices.Contains(ed.StructNames(), tc.Type) || !tc.When.Matches(importPaths) || marker != "" && !strings.Contains(ed.TypeDoc(tc.Type), marker) {
+				continue
+			}
+			dir := filepath.Dir(path)
+			if renames[dir] == nil {
+				renames[dir] = make(map[string]string)
+			}
+			renames[dir][tc.Type] = tc.RenameType
+		}
+	}
+
+	var spans bool
+	for _, dir := range slices.Sorted(maps.Keys(renames)) {
+		inDir, err := goFilesIn(dir)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, path := range inDir {
+			path = filepath.Clean(path)
+			ed, ok := scanned[path]
+			if !ok {
+				if ed, err = editor.ParseFile(path); err != nil {
+					return nil, false, err
+				}
+			}
+			for oldName, newName := range renames[dir] {
+				if slices.Contains(ed.StructNames(), newName) {
+					return nil, false, fmt.Errorf("rename type %s: type %s is already declared in %s", oldName, newName, path)
+				}
+				if slices.Contains(ed.StructNames(), oldName) || !ed.RefersTo(oldName) {
+					continue
+				}
+				if !ok {
+					return nil, false, fmt.Errorf("rename type %s: %s refers to it but is not processed", oldName, path)
+				}
+				spans = true
+			}
+		}
+	}
+	return renames, spans, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+func TestPackageRenames(t *testing.T) {
+	write := func(t *testing.T, files map[string]string) string {
+		dir := t.TempDir()
+		for name, src := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(src), 0644))
+		}
+		return dir
+	}
+	configs := []config.TypeConfig{{Type: "Old", RenameType: "New"}}
+
+	t.Run("renames references in other files", func(t *testing.T) {
+		dir := write(t, map[string]string{
+			"a.go": "package test\n\ntype Old struct {\n\tID int\n}\n",
+			"b.go": "package test\n\nfunc use(o Old) *Old { return &o }\n",
+		})
+		files := []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}
+
+		renames, spans, err := packageRenames(files, configs, "")
+		require.NoError(t, err)
+		assert.True(t, spans)
+		assert.Equal(t, map[string]map[string]string{dir: {"Old": "New"}}, renames)
+
+		var staged []fileResult
+		for _, path := range files {
+			res, err := editFile(path, configs, options{typeRenames: renames})
+			require.NoError(t, err)
+			staged = append(staged, res)
+		}
+		require.NoError(t, writeAll(staged))
+
+		content, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\ntype New struct {\n\tID int\n}\n", string(content))
+		content, err = os.ReadFile(files[1])
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\nfunc use(o New) *New { return &o }\n", string(content))
+	})
+
+	t.Run("unprocessed file refers to the type", func(t *testing.T) {
+		dir := write(t, map[string]string{
+			"a.go": "package test\n\ntype Old struct{}\n",
+			"b.go": "package test\n\nvar x Old\n",
+		})
+		_, _, err := packageRenames([]string{filepath.Join(dir, "a.go")}, configs, "")
+		assert.EqualError(t, err, "rename type Old: "+filepath.Join(dir, "b.go")+" refers to it but is not processed")
+	})
+
+	t.Run("new name declared in another file", func(t *testing.T) {
+		dir := write(t, map[string]string{
+			"a.go": "package test\n\ntype Old struct{}\n",
+			"b.go": "package test\n\ntype New int\n",
+		})
+		files := []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}
+		_, _, err := packageRenames(files, configs, "")
+		assert.EqualError(t, err, "rename type Old: type New is already declared in "+files[1])
+	})
+
+	t.Run("nothing to rename", func(t *testing.T) {
+		dir := write(t, map[string]string{"a.go": "package test\n\ntype Other struct{}\n"})
+		renames, spans, err := packageRenames([]string{filepath.Join(dir, "a.go")}, configs, "")
+		require.NoError(t, err)
+		assert.False(t, spans)
+		assert.Empty(t, renames)
+	})
+}
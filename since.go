@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// readStamp returns the time stored in a -since-file. A missing file yields
+// the zero time, so the first run processes every file.
+func readStamp(path string) (time.Time, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	since, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return since, nil
+}
+
+func writeStamp(path string, at time.Time) error {
+	return os.WriteFile(path, []byte(at.Format(time.RFC3339Nano)+"\n"), 0644)
+}
+
+// filterSince keeps the files modified after since. If the config file itself
+// changed after since, every file is kept, since the new config may apply to
+// files that did not change.
+func filterSince(files []string, configPath string, since time.Time) ([]string, error) {
+	if info, err := os.Stat(configPath); err == nil && info.ModTime().After(since) {
+		return files, nil
+	}
+
+	var result []string
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if info.ModTime().After(since) {
+			result = append(result, file)
+		}
+	}
+	return result, nil
+}
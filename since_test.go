@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterSince(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	since := time.Now().Add(-time.Hour)
+	old := since.Add(-time.Hour)
+
+	for _, name := range []string{"a.go", "b.go", "edit.yaml"} {
+		require.NoError(t, os.WriteFile(name, []byte("package a\n"), 0644))
+	}
+	require.NoError(t, os.Chtimes("a.go", old, old))
+
+	t.Run("skip unchanged", func(t *testing.T) {
+		require.NoError(t, os.Chtimes("edit.yaml", old, old))
+		files, err := filterSince([]string{"a.go", "b.go"}, "edit.yaml", since)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b.go"}, files)
+	})
+
+	t.Run("config changed", func(t *testing.T) {
+		now := time.Now()
+		require.NoError(t, os.Chtimes("edit.yaml", now, now))
+		files, err := filterSince([]string{"a.go", "b.go"}, "edit.yaml", since)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.go", "b.go"}, files)
+	})
+}
+
+func TestStamp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".editstruct-stamp")
+
+	since, err := readStamp(path)
+	require.NoError(t, err)
+	assert.True(t, since.IsZero())
+
+	at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, writeStamp(path, at))
+	since, err = readStamp(path)
+	require.NoError(t, err)
+	assert.True(t, at.Equal(since))
+
+	require.NoError(t, os.WriteFile(path, []byte("yesterday"), 0644))
+	_, err = readStamp(path)
+	assert.Error(t, err)
+}
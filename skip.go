@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+// mentionsConfig reports whether src may be affected by the configs, so files
+// that cannot match are not parsed at all. It only looks for the configured
+// type and var names anywhere in the raw source, including comments and
+// strings, so it may report true for a file without a match but never false
+// for one with a match.
+func mentionsConfig(src []byte, configs []config.TypeConfig) bool {
+	for _, tc := range configs {
+		if len(tc.ReplaceTypes) > 0 {
+			// Applies to any struct in the file.
+			return true
+		}
+		if tc.Type != "" && bytes.Contains(src, []byte(tc.Type)) {
+			return true
+		}
+		for name := range tc.Vars {
+			if bytes.Contains(src, []byte(name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+	"github.com/reddec/editstruct/internal/editor"
+)
+
+func TestMentionsConfig(t *testing.T) {
+	src := []byte("package test\n\n// Order is mentioned in a comment only.\ntype Invoice struct{}\n\nvar MaxSize int32\n")
+
+	assert.False(t, mentionsConfig(src, []config.TypeConfig{{Type: "Example"}}))
+	assert.True(t, mentionsConfig(src, []config.TypeConfig{{Type: "Example"}, {Type: "Invoice"}}))
+	assert.True(t, mentionsConfig(src, []config.TypeConfig{{Type: "Order"}}))
+	assert.True(t, mentionsConfig(src, []config.TypeConfig{{Vars: map[string]string{"MaxSize": "int64"}}}))
+	assert.False(t, mentionsConfig(src, []config.TypeConfig{{Vars: map[string]string{"Timeout": "int64"}}}))
+	assert.True(t, mentionsConfig(src, []config.TypeConfig{{ReplaceTypes: map[string]string{"float32": "float64"}}}))
+
+	t.Run("skipped file is not parsed", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "broken.go")
+		require.NoError(t, os.WriteFile(filePath, []byte("package test\n\nfunc {\n"), 0644))
+
+		res, err := editFile(filePath, []config.TypeConfig{{Type: "Example", Fields: map[string]string{"Total": "int64"}}}, options{})
+		require.NoError(t, err)
+		assert.False(t, res.modified)
+	})
+}
+
+// BenchmarkEditFile processes a tree where 9 of 10 files do not mention the
+// configured type, with and without the raw source check.
+func BenchmarkEditFile(b *testing.B) {
+	dir := b.TempDir()
+	var files []string
+	for i := range 100 {
+		typeName := fmt.Sprintf("Other%d", i)
+		if i%10 == 0 {
+			typeName = "Example"
+		}
+		var src []byte
+		src = fmt.Appendf(src, "package test\n\nimport \"time\"\n\n// %s is generated.\ntype %s struct {\n", typeName, typeName)
+		for j := range 50 {
+			src = fmt.Appendf(src, "\tField%d *int64 `json:\"field%d\"` // comment\n", j, j)
+		}
+		src = fmt.Appendf(src, "\tAt time.Time\n}\n")
+		path := filepath.Join(dir, fmt.Sprintf("file%d.go", i))
+		require.NoError(b, os.WriteFile(path, src, 0644))
+		files = append(files, path)
+	}
+	cfg := []config.TypeConfig{{Type: "Example", Fields: map[string]string{"Field1": "int64"}}}
+
+	b.Run("skip", func(b *testing.B) {
+		for b.Loop() {
+			for _, path := range files {
+				if _, err := editFile(path, cfg, options{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("parse all", func(b *testing.B) {
+		for b.Loop() {
+			for _, path := range files {
+				ed, err := editor.ParseFile(path)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := ed.ApplyConfig(cfg[0]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+// stdinPath names source read from stdin in messages.
+const stdinPath = "<stdin>"
+
+// editStdin reads Go source from r, applies the configuration and writes the
+// result to w. Source without edits is written back unchanged.
+func editStdin(r io.Reader, w io.Writer, configs []config.TypeConfig, opts options) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read: %w", err)
+	}
+	res, err := editSource(stdinPath, src, configs, opts)
+	if err != nil {
+		return err
+	}
+	for _, warning := range res.warnings {
+		warn(opts, "%s: %s", stdinPath, warning)
+	}
+	out := src
+	if res.modified {
+		out = res.ed.Output()
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+func TestEditStdin(t *testing.T) {
+	cfg := []config.TypeConfig{{Type: "Example", Fields: map[string]string{"CreatedAt": "time.Time"}}}
+
+	t.Run("edits source", func(t *testing.T) {
+		var out bytes.Buffer
+		err := editStdin(strings.NewReader("package test\n\ntype Example struct {\n\tCreatedAt string\n}\n"), &out, cfg, options{})
+		require.NoError(t, err)
+		assert.Equal(t, "package test\n\nimport (\n\t\"time\"\n)\n\ntype Example struct {\n\tCreatedAt time.Time\n}\n", out.String())
+	})
+
+	t.Run("unchanged source", func(t *testing.T) {
+		src := "package test\n\ntype Other struct {\n\tCreatedAt string\n}\n"
+		var out bytes.Buffer
+		require.NoError(t, editStdin(strings.NewReader(src), &out, cfg, options{}))
+		assert.Equal(t, src, out.String())
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		var out bytes.Buffer
+		err := editStdin(strings.NewReader("package test\n\ntype Example struct {\n"), &out, cfg, options{})
+		assert.ErrorContains(t, err, "<stdin>:")
+		assert.Empty(t, out.String())
+	})
+}
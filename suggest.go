@@ -0,0 +1,38 @@
+package main
+
+// maxSuggestDistance is the largest edit distance at which a field name is
+// offered as a likely typo fix.
+const maxSuggestDistance = 2
+
+// suggest returns the candidate closest to name, if it is within
+// maxSuggestDistance edits.
+func suggest(name string, candidates []string) (string, bool) {
+	best, bestDist := "", maxSuggestDistance+1
+	for _, c := range candidates {
+		if d := levenshtein(name, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best, best != ""
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
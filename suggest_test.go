@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggest(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("Total", "Total"))
+	assert.Equal(t, 1, levenshtein("Totl", "Total"))
+	assert.Equal(t, 2, levenshtein("Totla", "Total"))
+	assert.Equal(t, 3, levenshtein("", "abc"))
+
+	name, ok := suggest("Totl", []string{"ID", "Total", "Title"})
+	assert.True(t, ok)
+	assert.Equal(t, "Total", name)
+
+	_, ok = suggest("CreatedAt", []string{"ID", "Total"})
+	assert.False(t, ok)
+}
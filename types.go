@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/reddec/editstruct/internal/config"
+	"github.com/reddec/editstruct/internal/editor"
+)
+
+// packageTypes returns the names of the types declared in the packages of the
+// given files: every non-test Go file of their directories, as well as the
+// files themselves.
+func packageTypes(files []string) (map[string]bool, error) {
+	all := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		all[filepath.Clean(file)] = true
+		dirs[filepath.Dir(file)] = true
+	}
+	for dir := range dirs {
+		inDir, err := goFilesIn(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range inDir {
+			all[filepath.Clean(file)] = true
+		}
+	}
+
+	idx, err := indexTypes(slices.Sorted(maps.Keys(all)))
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for name := range idx.aliases {
+		names[name] = true
+	}
+	for name := range idx.defined {
+		names[name] = true
+	}
+	return names, nil
+}
+
+// checkLocalTypes fails if a field was given an unqualified type that is
+// neither predeclared, declared in the package, nor a type parameter of the
+// struct.
+func checkLocalTypes(ed *editor.Editor, applied []editor.FieldEdit, declared map[string]bool) error {
+	for _, fe := range applied {
+		structName, _, _ := strings.Cut(fe.Struct, ".")
+		params := ed.TypeParams(structName)
+		for _, name := range config.LocalTypes(fe.NewType) {
+			if !declared[name] && !slices.Contains(params, name) {
+				return fmt.Errorf("field %s.%s: type %s is not declared in the package", fe.Struct, fe.Field, name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+func TestCheckLocalTypes(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "order.go")
+	require.NoError(t, os.WriteFile(filePath, []byte(`package test
+
+type Order struct {
+	Status string
+	Items  []string
+}
+
+type Box[T any] struct {
+	Value any
+}
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "status.go"), []byte(`package test
+
+type status int
+`), 0644))
+
+	// Only order.go is processed, but status.go is in the same package.
+	declared, err := packageTypes([]string{filePath})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"Order": true, "Box": true, "status": true}, declared)
+	opts := options{strict: true, localTypes: declared}
+
+	t.Run("declared type", func(t *testing.T) {
+		_, err := editFile(filePath, []config.TypeConfig{
+			{Type: "Order", Fields: map[string]string{"Status": "status", "Items": "[]Order"}},
+			{Type: "Box", Fields: map[string]string{"Value": "*T"}},
+		}, opts)
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing type", func(t *testing.T) {
+		_, err := editFile(filePath, []config.TypeConfig{
+			{Type: "Order", Fields: map[string]string{"Status": "stauts"}},
+		}, opts)
+		assert.EqualError(t, err, "field Order.Status: type stauts is not declared in the package")
+	})
+
+	t.Run("without strict", func(t *testing.T) {
+		_, err := editFile(filePath, []config.TypeConfig{
+			{Type: "Order", Fields: map[string]string{"Status": "stauts"}},
+		}, options{})
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/reddec/editstruct/internal/config"
+	"github.com/reddec/editstruct/internal/editor"
+)
+
+// validate checks the config against the files without editing them. It
+// returns one message per configured type, field or var that exists in none of
+// the files, and per package selector whose import path could only be guessed.
+func validate(files []string, configs []config.TypeConfig) ([]string, error) {
+	editors := make([]*editor.Editor, 0, len(files))
+	for _, file := range files {
+		ed, err := editor.ParseFile(file)
+		if err != nil {
+			return nil, err
+		}
+		editors = append(editors, ed)
+	}
+
+	var problems []string
+	for _, tc := range configs {
+		var matching []*editor.Editor
+		for _, ed := range editors {
+			if tc.When.Matches(ed.ImportPaths()) {
+				matching = append(matching, ed)
+			}
+		}
+
+		if tc.Type != "" {
+			declaring := slices.DeleteFunc(slices.Clone(matching), func(ed *editor.Editor) bool {
+				return !slices.Contains(ed.StructNames(), tc.Type)
+			})
+			if len(declaring) == 0 {
+				problems = append(problems, fmt.Sprintf("type %s not found", tc.Type))
+			}
+			for _, name := range configuredFields(tc) {
+				if len(declaring) > 0 && !slices.ContainsFunc(declaring, func(ed *editor.Editor) bool { return hasConfiguredField(ed, tc, name) }) {
+					problems = append(problems, fmt.Sprintf("field %s.%s not found", tc.Type, name))
+				}
+			}
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(tc.Vars)) {
+			if !slices.ContainsFunc(matching, func(ed *editor.Editor) bool { return slices.Contains(ed.ValueNames(), name) }) {
+				problems = append(problems, fmt.Sprintf("var %s not found", name))
+			}
+		}
+
+		unknown := tc.UnknownPackages(tc.NewTypes()...)
+		slices.Sort(unknown)
+		subject := "type " + tc.Type
+		if tc.Type == "" {
+			subject = "document without type"
+		}
+		for _, alias := range slices.Compact(unknown) {
+			problems = append(problems, fmt.Sprintf("%s: unknown package %q", subject, alias))
+		}
+	}
+	return problems, nil
+}
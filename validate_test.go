@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	orderPath := filepath.Join(dir, "order.go")
+	err := os.WriteFile(orderPath, []byte(`package test
+
+type Order struct {
+	ID    string
+	Total *int64
+}
+`), 0644)
+	require.NoError(t, err)
+	varsPath := filepath.Join(dir, "vars.go")
+	err = os.WriteFile(varsPath, []byte(`package test
+
+var Timeout = 5
+`), 0644)
+	require.NoError(t, err)
+	files := []string{orderPath, varsPath}
+
+	t.Run("valid", func(t *testing.T) {
+		problems, err := validate(files, []config.TypeConfig{
+			{Type: "Order", Fields: map[string]string{"ID": "uuid.UUID", "Total": "uint64"}, ImportPaths: map[string]string{"uuid": "github.com/google/uuid"}},
+			{Vars: map[string]string{"Timeout": "time.Duration"}},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, problems)
+	})
+
+	t.Run("drift", func(t *testing.T) {
+		problems, err := validate(files, []config.TypeConfig{
+			{Type: "Order", Fields: map[string]string{"ID": "uuid.UUID", "Amount": "uint64"}, MapKey: map[string]string{"Index": "int"}},
+			{Type: "Invoice", Fields: map[string]string{"ID": "string"}},
+			{Vars: map[string]string{"Retries": "int"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"field Order.Amount not found",
+			"field Order.Index not found",
+			`type Order: unknown package "uuid"`,
+			"type Invoice not found",
+			"var Retries not found",
+		}, problems)
+	})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// verifyBuild type-checks the package in the current directory and every
+// package containing an edited file as they would be after writing results,
+// so errors in files the tool did not touch (such as code using a field whose
+// type changed) are caught. Imports of edited packages see the edits; other
+// imports are type-checked from source, which makes it much slower than
+// -verify.
+func verifyBuild(results []fileResult) error {
+	c := &buildChecker{
+		fset:     token.NewFileSet(),
+		edited:   make(map[string][]byte),
+		dirs:     make(map[string]bool),
+		packages: make(map[string]checkedPackage),
+	}
+	c.source = importer.ForCompiler(c.fset, "source", nil).(types.ImporterFrom)
+
+	check := map[string]bool{".": true}
+	for _, res := range results {
+		if !res.modified {
+			continue
+		}
+		path, err := filepath.Abs(res.path)
+		if err != nil {
+			return err
+		}
+		c.edited[path] = res.ed.Source()
+		c.dirs[filepath.Dir(path)] = true
+		check[filepath.Dir(filepath.Clean(res.path))] = true
+	}
+
+	for _, dir := range slices.Sorted(maps.Keys(check)) {
+		if _, err := c.checkDir(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildChecker type-checks packages with the edited sources applied. It is
+// also the importer of the packages it checks, so that a package importing an
+// edited one is checked against the edits.
+type buildChecker struct {
+	fset     *token.FileSet
+	edited   map[string][]byte // by absolute path
+	dirs     map[string]bool   // absolute directories with edited files
+	packages map[string]checkedPackage
+	source   types.ImporterFrom
+}
+
+type checkedPackage struct {
+	pkg *types.Package
+	err error
+}
+
+func (c *buildChecker) Import(path string) (*types.Package, error) {
+	return c.ImportFrom(path, ".", 0)
+}
+
+func (c *buildChecker) ImportFrom(path, dir string, mode types.ImportMode) (*types.Package, error) {
+	if bp, err := build.Default.Import(path, dir, build.FindOnly); err == nil && c.dirs[bp.Dir] {
+		return c.checkDir(bp.Dir)
+	}
+	return c.source.ImportFrom(path, dir, mode)
+}
+
+// checkDir type-checks the non-test Go files in dir that match the build
+// context. A directory without such files is skipped.
+func (c *buildChecker) checkDir(dir string) (*types.Package, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if checked, ok := c.packages[abs]; ok {
+		return checked.pkg, checked.err
+	}
+
+	pkg, err := c.check(dir)
+	c.packages[abs] = checkedPackage{pkg: pkg, err: err}
+	return pkg, err
+}
+
+func (c *buildChecker) check(dir string) (*types.Package, error) {
+	names, err := goFilesIn(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, name := range names {
+		if ok, err := build.Default.MatchFile(dir, filepath.Base(name)); err != nil || !ok {
+			continue
+		}
+		abs, err := filepath.Abs(name)
+		if err != nil {
+			return nil, err
+		}
+		src, ok := c.edited[abs]
+		if !ok {
+			if src, err = os.ReadFile(name); err != nil {
+				return nil, err
+			}
+		}
+		file, err := parser.ParseFile(c.fset, name, src, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	var errs []error
+	conf := types.Config{
+		Importer: c,
+		Error:    func(err error) { errs = append(errs, err) },
+	}
+	pkg, _ := conf.Check(files[0].Name.Name, c.fset, files, nil)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("type check: %w", errors.Join(errs...))
+	}
+	return pkg, nil
+}
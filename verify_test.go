@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+func TestVerifyBuild(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("types.go", []byte(`package test
+
+type Example struct {
+	Total *int64
+	Name  string
+}
+`), 0644))
+	require.NoError(t, os.WriteFile("use.go", []byte(`package test
+
+import "strings"
+
+func total(e Example) int64 {
+	return *e.Total
+}
+
+func name(e Example) string {
+	return strings.TrimSpace(e.Name)
+}
+`), 0644))
+
+	t.Run("compiles", func(t *testing.T) {
+		res, err := editFile("types.go", []config.TypeConfig{{Type: "Example", Fields: map[string]string{"Name": "string"}}}, options{})
+		require.NoError(t, err)
+		assert.NoError(t, verifyBuild([]fileResult{res}))
+	})
+
+	t.Run("breaks another file", func(t *testing.T) {
+		res, err := editFile("types.go", []config.TypeConfig{{Type: "Example", Fields: map[string]string{"Total": "uint64"}}}, options{})
+		require.NoError(t, err)
+		require.True(t, res.modified)
+
+		err = verifyBuild([]fileResult{res})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "use.go:6")
+		assert.Contains(t, err.Error(), "cannot indirect e.Total")
+	})
+}
+
+func TestVerifyBuild_Subpackages(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("go.mod", []byte("module example.com/m\n\ngo 1.22\n"), 0644))
+	require.NoError(t, os.Mkdir("inner", 0755))
+	boxPath := filepath.Join("inner", "box.go")
+	require.NoError(t, os.WriteFile(boxPath, []byte("package inner\n\ntype Box struct {\n\tN int32\n\tM int32\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join("inner", "get.go"), []byte("package inner\n\nfunc Get(b Box) int32 {\n\treturn b.N\n}\n"), 0644))
+	require.NoError(t, os.WriteFile("main.go", []byte("package main\n\nimport \"example.com/m/inner\"\n\nvar m int32 = inner.Box{}.M\n\nfunc main() {}\n"), 0644))
+
+	t.Run("edited package", func(t *testing.T) {
+		res, err := editFile(boxPath, []config.TypeConfig{{Type: "Box", Fields: map[string]string{"N": "int64"}}}, options{})
+		require.NoError(t, err)
+		require.True(t, res.modified)
+
+		err = verifyBuild([]fileResult{res})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), filepath.Join("inner", "get.go")+":4")
+	})
+
+	t.Run("package importing an edited one", func(t *testing.T) {
+		res, err := editFile(boxPath, []config.TypeConfig{{Type: "Box", Fields: map[string]string{"M": "int64"}}}, options{})
+		require.NoError(t, err)
+		require.True(t, res.modified)
+
+		err = verifyBuild([]fileResult{res})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "main.go:5")
+	})
+
+	t.Run("compiles", func(t *testing.T) {
+		res, err := editFile(boxPath, []config.TypeConfig{{Type: "Box", Fields: map[string]string{"M": "int32"}}}, options{})
+		require.NoError(t, err)
+		assert.NoError(t, verifyBuild([]fileResult{res}))
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func writeResult(res fileResult) error {
+	if !res.modified {
+		return nil
+	}
+	return res.ed.WriteTo(res.path)
+}
+
+// writeAll writes every modified file. If any write fails, files written so
+// far (including the failed one) are restored to their original content.
+func writeAll(results []fileResult) error {
+	type backup struct {
+		path    string
+		content []byte
+		mode    os.FileMode
+	}
+	var written []backup
+
+	rollback := func() {
+		for i := len(written) - 1; i >= 0; i-- {
+			b := written[i]
+			if err := os.WriteFile(b.path, b.content, b.mode); err != nil {
+				fmt.Fprintf(os.Stderr, "rollback %s: %v\n", b.path, err)
+			}
+		}
+	}
+
+	for _, res := range results {
+		if !res.modified {
+			continue
+		}
+		info, err := os.Stat(res.path)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("write %s: %w", res.path, err)
+		}
+		content, err := os.ReadFile(res.path)
+		if err != nil {
+			rollback()
+			return fmt.Errorf("write %s: %w", res.path, err)
+		}
+		written = append(written, backup{path: res.path, content: content, mode: info.Mode().Perm()})
+
+		if err := res.ed.WriteTo(res.path); err != nil {
+			rollback()
+			return fmt.Errorf("write %s: %w", res.path, err)
+		}
+	}
+	return nil
+}
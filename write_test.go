@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddec/editstruct/internal/config"
+)
+
+func TestWriteAll(t *testing.T) {
+	cfg := []config.TypeConfig{
+		{Type: "Example", Fields: map[string]string{"Total": "uint64"}},
+	}
+	original := `package test
+
+type Example struct {
+	Total *int64
+}
+`
+
+	t.Run("writes all files", func(t *testing.T) {
+		dir := t.TempDir()
+		first := filepath.Join(dir, "a.go")
+		second := filepath.Join(dir, "b.go")
+		require.NoError(t, os.WriteFile(first, []byte(original), 0644))
+		require.NoError(t, os.WriteFile(second, []byte(original), 0644))
+
+		var staged []fileResult
+		for _, path := range []string{first, second} {
+			res, err := editFile(path, cfg, options{})
+			require.NoError(t, err)
+			staged = append(staged, res)
+		}
+
+		content, err := os.ReadFile(first)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content), "nothing is written before commit")
+
+		require.NoError(t, writeAll(staged))
+		for _, path := range []string{first, second} {
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "Total uint64")
+		}
+	})
+
+	t.Run("rolls back on failure", func(t *testing.T) {
+		dir := t.TempDir()
+		first := filepath.Join(dir, "a.go")
+		second := filepath.Join(dir, "b.go")
+		require.NoError(t, os.WriteFile(first, []byte(original), 0644))
+		require.NoError(t, os.WriteFile(second, []byte(original), 0644))
+
+		var staged []fileResult
+		for _, path := range []string{first, second} {
+			res, err := editFile(path, cfg, options{})
+			require.NoError(t, err)
+			staged = append(staged, res)
+		}
+
+		require.NoError(t, os.Remove(second))
+		require.NoError(t, os.Mkdir(second, 0755))
+
+		err := writeAll(staged)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "b.go")
+
+		content, err := os.ReadFile(first)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(content))
+	})
+}